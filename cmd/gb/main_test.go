@@ -1,12 +1,14 @@
 package main
 
 import (
+	"os"
 	"os/exec"
 	"testing"
 )
 
 func TestMainCommand(t *testing.T) {
 	cmd := exec.Command("go", "run", "main.go", "-list")
+	cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+t.TempDir(), "XDG_STATE_HOME="+t.TempDir())
 	if err := cmd.Run(); err != nil {
 		// This might fail, but at least it doesn't panic
 		t.Logf("Command failed (expected): %v", err)
@@ -0,0 +1,501 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupRefPrefix = "refs/gb/backup/"
+	journalFile     = "journal.json"
+	historyLimit    = 20
+)
+
+type journalEntry struct {
+	RelPath string `json:"relPath"`
+	Path    string `json:"path"`
+	Branch  string `json:"branch"`
+	Head    string `json:"head"`
+	Stash   string `json:"stash,omitempty"`
+
+	// MovedBranch is another local branch the run moved, from MovedFrom.
+	MovedBranch string `json:"movedBranch,omitempty"`
+	MovedFrom   string `json:"movedFrom,omitempty"`
+
+	// After and AfterBranch are where the run left the repo, so undo can
+	// tell whether it has moved on since.
+	After       string `json:"after,omitempty"`
+	AfterBranch string `json:"afterBranch,omitempty"`
+}
+
+type runJournal struct {
+	ID          string         `json:"id"`
+	Time        time.Time      `json:"time"`
+	Op          string         `json:"op"`
+	Description string         `json:"description"`
+	Root        string         `json:"root"`
+	Repos       []journalEntry `json:"repos"`
+	UndoneAt    *time.Time     `json:"undoneAt,omitempty"`
+}
+
+type backupSession struct {
+	mu      sync.Mutex
+	journal runJournal
+	// captured and kept track repos by RelPath, so a repo retried within
+	// the run keeps the state from before its first attempt.
+	captured map[string]*journalEntry
+	kept     map[string]bool
+}
+
+func stateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "gb"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "gb"), nil
+}
+
+func runsDir() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "runs"), nil
+}
+
+func newRunID() string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+// newBackupSession journals under the given run ID, which is shared with the
+// run's logs when it has any.
+func newBackupSession(id, root, op, description string) *backupSession {
+	return &backupSession{journal: runJournal{
+		ID:          id,
+		Time:        time.Now(),
+		Op:          op,
+		Description: description,
+		Root:        root,
+	}, captured: make(map[string]*journalEntry), kept: make(map[string]bool)}
+}
+
+func (b *backupSession) refName(kind string) string {
+	return backupRefPrefix + b.journal.ID + "/" + kind
+}
+
+// capture records the repo's branch and HEAD (and, with stash, any dirty
+// state) under refs/gb/backup/<run-id> before it is mutated. A repo that is
+// captured again in the same run gets its first entry back, and one with no
+// commits, which has nothing to back up, gets nil.
+func (b *backupSession) capture(r RepoInfo, stash bool) (*journalEntry, error) {
+	b.mu.Lock()
+	prev := b.captured[r.RelPath]
+	b.mu.Unlock()
+	if prev != nil {
+		return prev, nil
+	}
+	if !checkHasCommits(r.Path) {
+		return nil, nil
+	}
+
+	head, err := gitOutput(r.Path, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("read HEAD: %w", err)
+	}
+	branch, _ := gitOutput(r.Path, "branch", "--show-current")
+	entry := &journalEntry{RelPath: r.RelPath, Path: r.Path, Branch: branch, Head: head}
+
+	if err := newCmd("git", "-C", r.Path, "update-ref", b.refName("head"), head).Run(); err != nil {
+		return nil, fmt.Errorf("write backup ref: %w", err)
+	}
+	if stash {
+		if sha, _ := gitOutput(r.Path, "stash", "create"); sha != "" {
+			if err := newCmd("git", "-C", r.Path, "update-ref", b.refName("stash"), sha).Run(); err != nil {
+				return nil, fmt.Errorf("write stash ref: %w", err)
+			}
+			entry.Stash = sha
+		}
+	}
+	b.mu.Lock()
+	b.captured[r.RelPath] = entry
+	b.mu.Unlock()
+	return entry, nil
+}
+
+// captureBranch also records the tip of a local branch other than the
+// current one that the run is about to move, so undo can put it back.
+func (b *backupSession) captureBranch(entry *journalEntry, branch string) error {
+	if entry == nil || branch == "" || branch == entry.Branch || entry.MovedBranch != "" {
+		return nil
+	}
+	tip, err := gitOutput(entry.Path, "rev-parse", "--verify", "-q", "refs/heads/"+branch)
+	if err != nil {
+		return nil
+	}
+	if err := newCmd("git", "-C", entry.Path, "update-ref", b.refName("branch"), tip).Run(); err != nil {
+		return fmt.Errorf("write branch backup ref: %w", err)
+	}
+	entry.MovedBranch, entry.MovedFrom = branch, tip
+	return nil
+}
+
+// keep adds the entry to the journal if the repo may have changed, and
+// otherwise drops its backup refs. Once kept, an entry stays in the journal
+// however later attempts at the repo turn out; they only update where the
+// run left it.
+func (b *backupSession) keep(entry *journalEntry, skipped bool) {
+	if entry == nil {
+		return
+	}
+	head, _ := gitOutput(entry.Path, "rev-parse", "HEAD")
+	branch, _ := gitOutput(entry.Path, "branch", "--show-current")
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.kept[entry.RelPath] {
+		for i := range b.journal.Repos {
+			if b.journal.Repos[i].RelPath == entry.RelPath {
+				b.journal.Repos[i].After, b.journal.Repos[i].AfterBranch = head, branch
+			}
+		}
+		return
+	}
+	if skipped || (head == entry.Head && branch == entry.Branch && entry.Stash == "") {
+		deleteBackupRefs(entry.Path, b.journal.ID)
+		delete(b.captured, entry.RelPath)
+		return
+	}
+	b.kept[entry.RelPath] = true
+	entry.After, entry.AfterBranch = head, branch
+	b.journal.Repos = append(b.journal.Repos, *entry)
+}
+
+// logBackupFailure notes in a repo's log why it was left untouched.
+func logBackupFailure(logFile *os.File, err error) {
+	if logFile != nil {
+		_, _ = fmt.Fprintf(logFile, "Backup failed, not touching the repo: %v\n", err)
+	}
+}
+
+// deleteBackupRefs removes the refs a run wrote in the repo at path.
+func deleteBackupRefs(path, id string) {
+	for _, kind := range []string{"head", "stash", "branch"} {
+		_ = newCmd("git", "-C", path, "update-ref", "-d", backupRefPrefix+id+"/"+kind).Run()
+	}
+}
+
+func (b *backupSession) save() error {
+	if len(b.journal.Repos) == 0 {
+		return nil
+	}
+	sort.Slice(b.journal.Repos, func(i, j int) bool { return b.journal.Repos[i].RelPath < b.journal.Repos[j].RelPath })
+	return writeJournal(&b.journal)
+}
+
+func (b *backupSession) report(cfg *Config) {
+	if len(b.journal.Repos) == 0 {
+		return
+	}
+	fmt.Fprintf(cfg.infoWriter(), "\nBackup saved for %d repos as run %s (undo with: gb undo %s)\n",
+		len(b.journal.Repos), StyleInfo.Render(b.journal.ID), b.journal.ID)
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := newCmd("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+func writeJournal(j *runJournal) error {
+	dir, err := runsDir()
+	if err != nil {
+		return err
+	}
+	runDir := filepath.Join(dir, j.ID)
+	if err := os.MkdirAll(runDir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(runDir, journalFile), data, 0o644)
+}
+
+func readJournal(id string) (*runJournal, error) {
+	if err := checkRunID(id); err != nil {
+		return nil, err
+	}
+	dir, err := runsDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, id, journalFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("run %q not found", id)
+		}
+		return nil, err
+	}
+	var j runJournal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("run %q: %w", id, err)
+	}
+	return &j, nil
+}
+
+func listJournals() ([]*runJournal, error) {
+	dir, err := runsDir()
+	if err != nil {
+		return nil, err
+	}
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var journals []*runJournal
+	for _, e := range dirEntries {
+		if !e.IsDir() {
+			continue
+		}
+		if j, err := readJournal(e.Name()); err == nil {
+			journals = append(journals, j)
+		}
+	}
+	sort.Slice(journals, func(i, j int) bool { return journals[i].ID > journals[j].ID })
+	return journals, nil
+}
+
+func showHistory() error {
+	journals, err := listJournals()
+	if err != nil {
+		return err
+	}
+	if len(journals) == 0 {
+		fmt.Println("No recorded runs.")
+		return nil
+	}
+	if len(journals) > historyLimit {
+		journals = journals[:historyLimit]
+	}
+
+	for _, j := range journals {
+		status := ""
+		if j.UndoneAt != nil {
+			status = " " + StyleSkipped.Render("(undone "+j.UndoneAt.Format("2006-01-02 15:04:05")+")")
+		}
+		fmt.Printf("%s %s%s\n", StyleBold.Render("Run:"), StyleSuccess.Render(j.ID), status)
+		fmt.Printf("%s  %s  %s\n", StyleDim.Render(j.Time.Format("2006-01-02 15:04:05")), j.Description, StyleDim.Render(j.Root))
+		fmt.Println(StyleDim.Render("-----------------"))
+		for _, e := range j.Repos {
+			from := e.Branch
+			if from == "" {
+				from = "detached"
+			}
+			fmt.Printf("%s %s\n", e.RelPath, StyleDim.Render(fmt.Sprintf("(%s @ %.7s)", from, e.Head)))
+		}
+		fmt.Println(StyleDim.Render("================="))
+	}
+	return nil
+}
+
+func undoRun(ctx context.Context, id string, workers int, cfg *Config) error {
+	var j *runJournal
+	if id == "" {
+		journals, err := listJournals()
+		if err != nil {
+			return err
+		}
+		for _, candidate := range journals {
+			if candidate.UndoneAt == nil {
+				j = candidate
+				break
+			}
+		}
+		if j == nil {
+			return fmt.Errorf("no run to undo")
+		}
+	} else {
+		var err error
+		if j, err = readJournal(id); err != nil {
+			return err
+		}
+	}
+
+	out := newResultWriter(cfg.Output, "undo")
+	repos := make([]RepoInfo, 0, len(j.Repos))
+	entries := make(map[string]journalEntry, len(j.Repos))
+	for _, e := range j.Repos {
+		repos = append(repos, RepoInfo{Path: e.Path, RelPath: e.RelPath})
+		entries[e.RelPath] = e
+	}
+
+	opDesc := fmt.Sprintf("gb undo %s (%s)", j.ID, j.Description)
+	if !cfg.Yes {
+		if err := cfg.checkConfirmable(); err != nil {
+			return err
+		}
+		// Without --force dirty repos are skipped, so nothing is discarded.
+		var discarded []repoPreflightInfo
+		if cfg.Force {
+			discarded = preflightScan(ctx, repos, workers)
+		}
+		if !PromptConfirmDestructive(cfg.infoWriter(), opDesc, len(repos), discarded) {
+			_, _ = fmt.Fprintln(cfg.infoWriter(), "Aborted.")
+			return nil
+		}
+	}
+
+	fmt.Fprintln(cfg.infoWriter(), StyleInfo.Render(fmt.Sprintf("Undoing run %s (%s) in %d repos with %d workers...", j.ID, j.Description, len(repos), min(workers, len(repos)))))
+
+	progress := cfg.newProgress(repos, "Undoing "+j.ID, workers, nil)
+	progress.confirmRequeues()
+	stop := progress.start()
+
+	results := runPoolWith(ctx, repos, workers, newRunOptions[CommandResult](cfg, out, progress), func(ctx context.Context, r RepoInfo) CommandResult {
+		progress.UpdateStatus(r.RelPath, statusProcessing, "")
+		res := restoreEntry(ctx, j.Op, entries[r.RelPath], cfg.Force)
+		switch {
+		case res.Skipped:
+			progress.UpdateStatus(r.RelPath, statusSkipped, res.Error.Error())
+		case res.Error != nil:
+			progress.UpdateStatus(r.RelPath, statusFailed, res.Error.Error())
+		default:
+			progress.UpdateStatus(r.RelPath, statusCompleted, "")
+		}
+		return res
+	})
+
+	failed, skipped, cancelled := 0, 0, 0
+	for _, res := range results {
+		switch {
+		case res.Cancelled:
+			cancelled++
+		case res.Skipped:
+			skipped++
+		case res.Error != nil:
+			failed++
+		}
+	}
+
+	stop()
+	reportCI(cfg, "undo "+j.ID, nil, results)
+
+	if failed == 0 && skipped == 0 && cancelled == 0 {
+		now := time.Now()
+		j.UndoneAt = &now
+		if err := writeJournal(j); err != nil {
+			return err
+		}
+		for _, e := range j.Repos {
+			deleteBackupRefs(e.Path, j.ID)
+		}
+	}
+
+	if out.enabled() {
+		reportTimings(cfg, results)
+		return out.finishRun(failed, cancelled)
+	}
+
+	sort.Slice(results, func(a, b int) bool { return results[a].RelPath < results[b].RelPath })
+	for _, res := range results {
+		if res.Skipped {
+			fmt.Printf("%s %s\n", StyleSkipped.Render(res.RelPath+":"), res.Error)
+		} else if res.Error != nil {
+			fmt.Printf("%s %s\n", StyleFailed.Render(res.RelPath+":"), res.Error)
+			if res.Output != "" {
+				fmt.Println(StyleDim.Render(strings.TrimSpace(res.Output)))
+			}
+		}
+	}
+
+	fmt.Println("\n" + StyleBold.Render("--- Summary ---"))
+	fmt.Printf("Undo %s: %s restored, %s failed%s%s\n",
+		j.ID,
+		StyleSuccess.Render(fmt.Sprintf("%d", len(results)-failed-skipped-cancelled)),
+		StyleFailed.Render(fmt.Sprintf("%d", failed)),
+		skippedNote(skipped),
+		cancelledNote(cancelled))
+	reportTimings(cfg, results)
+
+	return runError(failed, cancelled)
+}
+
+// changedSinceRun describes how a repo differs from where the run left it,
+// or returns "" if it doesn't.
+func changedSinceRun(e journalEntry) string {
+	if status := getDirtyStatus(e.Path); status != "" {
+		return "has " + status
+	}
+	if e.After == "" {
+		return ""
+	}
+	head, _ := gitOutput(e.Path, "rev-parse", "HEAD")
+	branch, _ := gitOutput(e.Path, "branch", "--show-current")
+	if head != e.After || branch != e.AfterBranch {
+		return "has moved since the run"
+	}
+	return ""
+}
+
+// restoreEntry puts a repo back as the journal recorded it. Unless forced,
+// a repo with work the undo would discard is skipped.
+func restoreEntry(ctx context.Context, op string, e journalEntry, force bool) CommandResult {
+	if !force {
+		if change := changedSinceRun(e); change != "" {
+			return CommandResult{RelPath: e.RelPath, Skipped: true, Error: fmt.Errorf("%s; use --force to undo it anyway", change)}
+		}
+	}
+
+	var steps [][]string
+	switch {
+	case op == "switch" && e.Branch != "":
+		steps = append(steps, []string{"switch", e.Branch})
+	case op == "snapshot" && e.Branch != "":
+		steps = append(steps, []string{"switch", "-C", e.Branch, e.Head})
+	case op == "switch" || op == "snapshot":
+		steps = append(steps, []string{"switch", "--detach", e.Head})
+	default:
+		if current, _ := gitOutput(e.Path, "branch", "--show-current"); current != e.Branch {
+			return CommandResult{RelPath: e.RelPath, Error: fmt.Errorf("now on %q, expected %q; switch back first", current, e.Branch)}
+		}
+		mode := "--hard"
+		if op == "soft" {
+			mode = "--soft"
+		}
+		steps = append(steps, []string{"reset", mode, e.Head})
+		if e.Stash != "" {
+			steps = append(steps, []string{"stash", "apply", e.Stash})
+		}
+	}
+	if e.MovedBranch != "" {
+		steps = append(steps, []string{"branch", "-f", e.MovedBranch, e.MovedFrom})
+	}
+
+	var output strings.Builder
+	for _, args := range steps {
+		gitOut, _, err := executeGitCommandWithRetry(ctx, e.Path, args...)
+		output.Write(gitOut)
+		if err != nil {
+			return CommandResult{RelPath: e.RelPath, Output: output.String(), Error: fmt.Errorf("git %s: %w", strings.Join(args, " "), err), ExitCode: exitCodeOf(err)}
+		}
+	}
+	return CommandResult{RelPath: e.RelPath, Output: output.String()}
+}
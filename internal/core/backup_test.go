@@ -0,0 +1,222 @@
+package core
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runQuiet(t *testing.T, fn func() error) error {
+	t.Helper()
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := fn()
+	_ = w.Close()
+	os.Stdout = oldStdout
+	_, _ = io.ReadAll(r)
+	return err
+}
+
+func TestBackupKeepDropsUnchangedRepo(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	repoDir := t.TempDir()
+	createGitRepo(t, repoDir)
+
+	session := newBackupSession(newRunID(), repoDir, "switch", "git switch main")
+	entry, err := session.capture(RepoInfo{Path: repoDir, RelPath: "repo"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.keep(entry, false)
+
+	if len(session.journal.Repos) != 0 {
+		t.Errorf("expected unchanged repo to be dropped, got %+v", session.journal.Repos)
+	}
+	if out, _ := gitOutput(repoDir, "for-each-ref", backupRefPrefix); out != "" {
+		t.Errorf("expected backup refs to be removed, got %s", out)
+	}
+}
+
+func TestUndoHardResetRestoresHeadAndDirtyState(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	repoDir, _ := makeRepoWithRemote(t)
+	writeFile(t, repoDir, "local.txt", "local commit")
+	runCmd(t, repoDir, "git", "add", ".")
+	runCmd(t, repoDir, "git", "commit", "-m", "local")
+	head, _ := gitOutput(repoDir, "rev-parse", "HEAD")
+	writeFile(t, repoDir, "README.md", "dirty")
+
+	repo := RepoInfo{Path: repoDir, RelPath: "repo"}
+	session := newBackupSession(newRunID(), repoDir, "hard", "git reset --hard origin/main")
+	entry, err := session.capture(repo, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := processSingleReset(repo, "main", "hard", "origin", nil); !res.Success {
+		t.Fatalf("reset failed: %+v", res)
+	}
+	session.keep(entry, false)
+	if err := session.save(); err != nil {
+		t.Fatal(err)
+	}
+	if refs, _ := gitOutput(repoDir, "for-each-ref", "--format=%(refname)", backupRefPrefix+session.journal.ID); !strings.Contains(refs, "/stash") {
+		t.Errorf("expected head and stash backup refs, got %q", refs)
+	}
+
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+	cfg.Yes = true
+	if err := runQuiet(t, func() error { return undoRun(context.Background(), "", 2, cfg) }); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+
+	if after, _ := gitOutput(repoDir, "rev-parse", "HEAD"); after != head {
+		t.Errorf("expected HEAD %s after undo, got %s", head, after)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repoDir, "README.md")); string(data) != "dirty" {
+		t.Errorf("expected dirty state to be restored, got %q", data)
+	}
+	j, err := readJournal(session.journal.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.UndoneAt == nil {
+		t.Error("expected run to be marked as undone")
+	}
+	if refs, _ := gitOutput(repoDir, "for-each-ref", backupRefPrefix); refs != "" {
+		t.Errorf("expected backup refs to be removed after undo, got %s", refs)
+	}
+}
+
+func TestBackupRetryKeepsFirstCapture(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	repoDir := t.TempDir()
+	createGitRepo(t, repoDir)
+	head, _ := gitOutput(repoDir, "rev-parse", "HEAD")
+	repo := RepoInfo{Path: repoDir, RelPath: "repo"}
+
+	session := newBackupSession(newRunID(), repoDir, "hard", "git reset --hard")
+	entry, err := session.capture(repo, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runCmd(t, repoDir, "git", "commit", "--allow-empty", "-m", "first attempt")
+	session.keep(entry, false)
+
+	// A requeued attempt sees the repo as the first attempt left it.
+	retry, err := session.capture(repo, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runCmd(t, repoDir, "git", "commit", "--allow-empty", "-m", "second attempt")
+	session.keep(retry, true)
+
+	if len(session.journal.Repos) != 1 || session.journal.Repos[0].Head != head {
+		t.Errorf("expected one entry at the original HEAD %s, got %+v", head, session.journal.Repos)
+	}
+	if ref, _ := gitOutput(repoDir, "rev-parse", session.refName("head")); ref != head {
+		t.Errorf("expected the backup ref to keep %s, got %s", head, ref)
+	}
+}
+
+func TestSwitchBranchesRecordsRunForUndo(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo1")
+	createGitRepo(t, repoDir)
+	runCmd(t, repoDir, "git", "branch", "feature")
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+	if err := runQuiet(t, func() error { return switchBranches(context.Background(), tmpDir, "feature", 2, cfg) }); err != nil {
+		t.Fatalf("switch failed: %v", err)
+	}
+
+	journals, err := listJournals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(journals) != 1 || journals[0].Op != "switch" || len(journals[0].Repos) != 1 || journals[0].Repos[0].Branch != "main" {
+		t.Fatalf("unexpected journals: %+v", journals)
+	}
+
+	cfg.Yes = true
+	if err := runQuiet(t, func() error { return undoRun(context.Background(), journals[0].ID, 2, cfg) }); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+	if branch, _ := gitOutput(repoDir, "branch", "--show-current"); branch != "main" {
+		t.Errorf("expected main after undo, got %s", branch)
+	}
+}
+
+func TestUndoSkipsReposChangedSinceRun(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo1")
+	createGitRepo(t, repoDir)
+	runCmd(t, repoDir, "git", "branch", "feature")
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+	if err := runQuiet(t, func() error { return switchBranches(context.Background(), tmpDir, "feature", 2, cfg) }); err != nil {
+		t.Fatalf("switch failed: %v", err)
+	}
+	journals, err := listJournals()
+	if err != nil || len(journals) != 1 {
+		t.Fatalf("expected one journal, got %+v (%v)", journals, err)
+	}
+	id := journals[0].ID
+
+	if err := runQuiet(t, func() error { return undoRun(context.Background(), id, 2, cfg) }); err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Fatalf("expected undo under -o to need --yes, got %v", err)
+	}
+
+	cfg.Yes = true
+	writeFile(t, repoDir, "wip.txt", "uncommitted")
+	for _, name := range []string{"dirty", "moved"} {
+		if name == "moved" {
+			runCmd(t, repoDir, "git", "add", ".")
+			runCmd(t, repoDir, "git", "commit", "-m", "more work")
+		}
+		if err := runQuiet(t, func() error { return undoRun(context.Background(), id, 2, cfg) }); err != nil {
+			t.Fatalf("%s: undo failed: %v", name, err)
+		}
+		if branch, _ := gitOutput(repoDir, "branch", "--show-current"); branch != "feature" {
+			t.Fatalf("%s: expected the repo to be skipped, got branch %s", name, branch)
+		}
+		if j, _ := readJournal(id); j.UndoneAt != nil {
+			t.Fatalf("%s: expected the run to stay undoable", name)
+		}
+	}
+
+	cfg.Force = true
+	if err := runQuiet(t, func() error { return undoRun(context.Background(), id, 2, cfg) }); err != nil {
+		t.Fatalf("forced undo failed: %v", err)
+	}
+	if branch, _ := gitOutput(repoDir, "branch", "--show-current"); branch != "main" {
+		t.Errorf("expected --force to switch back to main, got %s", branch)
+	}
+}
+
+func TestSwitchLeavesRepoAloneWhenBackupFails(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo1")
+	createGitRepo(t, repoDir)
+	runCmd(t, repoDir, "git", "branch", "feature")
+	// A file where the backup refs' directory should be makes update-ref fail.
+	writeFile(t, filepath.Join(repoDir, ".git", "refs"), "gb", "")
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+	if err := runQuiet(t, func() error { return switchBranches(context.Background(), tmpDir, "feature", 2, cfg) }); err == nil {
+		t.Fatal("expected the switch to fail when its backup can't be written")
+	}
+	if branch, _ := gitOutput(repoDir, "branch", "--show-current"); branch != "main" {
+		t.Errorf("expected the repo to stay on main, got %s", branch)
+	}
+}
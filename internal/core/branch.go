@@ -4,9 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
-	"runtime"
 	"sort"
 	"strings"
 	"sync"
@@ -21,20 +20,27 @@ const (
 	retryDelay           = 2 * time.Second
 )
 
-var errReposFailed = errors.New("one or more repos failed")
+var (
+	errReposFailed    = errors.New("one or more repos failed")
+	errReposCancelled = errors.New("one or more repos were cancelled")
+)
 
 type BranchResult struct {
 	RelPath string
 	Branch  string
 	Error   error
+	repoTiming
 }
 
 type CommandResult struct {
-	RelPath string
-	Output  string
-	Error   error
-	Retries int
-	Skipped bool
+	RelPath   string
+	Output    string
+	Error     error
+	ExitCode  int
+	Retries   int
+	Skipped   bool
+	Cancelled bool
+	repoTiming
 }
 
 func executeGitCommandWithRetry(ctx context.Context, dir string, args ...string) ([]byte, int, error) {
@@ -43,7 +49,7 @@ func executeGitCommandWithRetry(ctx context.Context, dir string, args ...string)
 
 	for attempt := range maxRetries {
 		cmdCtx, cancel := context.WithTimeout(ctx, gitCommandTimeout)
-		cmd := exec.CommandContext(cmdCtx, "git", args...)
+		cmd := newCmdContext(cmdCtx, "git", args...)
 		cmd.Dir = dir
 
 		output, lastErr = cmd.CombinedOutput()
@@ -52,13 +58,7 @@ func executeGitCommandWithRetry(ctx context.Context, dir string, args ...string)
 		if lastErr == nil {
 			return output, attempt, nil
 		}
-
-		if cmdCtx.Err() != nil {
-			if attempt < maxRetries-1 {
-				time.Sleep(retryDelay)
-				continue
-			}
-		} else {
+		if !retryAfterTimeout(ctx, cmdCtx, attempt) {
 			return output, attempt, lastErr
 		}
 	}
@@ -66,12 +66,26 @@ func executeGitCommandWithRetry(ctx context.Context, dir string, args ...string)
 	return output, maxRetries - 1, lastErr
 }
 
-func executeGitCommandWithRetryToFile(ctx context.Context, dir string, logFile *os.File, args ...string) (int, error) {
+// retryAfterTimeout waits before another attempt when the command hit its
+// own timeout. A cancelled run or repo is never retried.
+func retryAfterTimeout(ctx, cmdCtx context.Context, attempt int) bool {
+	if ctx.Err() != nil || !errors.Is(cmdCtx.Err(), context.DeadlineExceeded) || attempt >= maxRetries-1 {
+		return false
+	}
+	select {
+	case <-time.After(retryDelay):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func executeGitCommandWithRetryToFile(ctx context.Context, dir string, logFile io.Writer, args ...string) (int, error) {
 	var lastErr error
 
 	for attempt := range maxRetries {
 		cmdCtx, cancel := context.WithTimeout(ctx, gitCommandTimeout)
-		cmd := exec.CommandContext(cmdCtx, "git", args...)
+		cmd := newCmdContext(cmdCtx, "git", args...)
 		cmd.Dir = dir
 
 		cmd.Stdout = logFile
@@ -83,33 +97,23 @@ func executeGitCommandWithRetryToFile(ctx context.Context, dir string, logFile *
 		if lastErr == nil {
 			return attempt, nil
 		}
-
-		if cmdCtx.Err() != nil {
-			if attempt < maxRetries-1 {
-				_, _ = fmt.Fprintf(logFile, "\n--- Retry %d/%d after timeout ---\n", attempt+1, maxRetries)
-				time.Sleep(retryDelay)
-				continue
-			}
-		} else {
+		if !retryAfterTimeout(ctx, cmdCtx, attempt) {
 			_, _ = fmt.Fprintf(logFile, "\n--- Command failed: %s ---\n", lastErr)
 			return attempt, lastErr
 		}
+		_, _ = fmt.Fprintf(logFile, "\n--- Retry %d/%d after timeout ---\n", attempt+1, maxRetries)
 	}
 
 	return maxRetries - 1, lastErr
 }
 
-func executeShellCommandToFile(ctx context.Context, dir string, logFile *os.File, command string) error {
+func executeShellCommandToFile(ctx context.Context, dir string, logFile io.Writer, command string, env []string) error {
 	cmdCtx, cancel := context.WithTimeout(ctx, gitCommandTimeout)
 	defer cancel()
 
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		cmd = exec.CommandContext(cmdCtx, "cmd", "/c", command)
-	} else {
-		cmd = exec.CommandContext(cmdCtx, "sh", "-c", command)
-	}
+	cmd := newShellCmdContext(cmdCtx, command)
 	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
 
 	cmd.Stdout = logFile
 	cmd.Stderr = logFile
@@ -124,7 +128,7 @@ func executeShellCommandToFile(ctx context.Context, dir string, logFile *os.File
 }
 
 func getBranch(path string) (string, error) {
-	cmd := exec.Command("git", "branch", "--show-current")
+	cmd := newCmd("git", "branch", "--show-current")
 	cmd.Dir = path
 	if out, err := cmd.Output(); err == nil {
 		if branch := strings.TrimSpace(string(out)); branch != "" {
@@ -132,7 +136,7 @@ func getBranch(path string) (string, error) {
 		}
 	}
 
-	cmd = exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd = newCmd("git", "rev-parse", "--abbrev-ref", "HEAD")
 	cmd.Dir = path
 	out, err := cmd.Output()
 	if err != nil {
@@ -141,7 +145,7 @@ func getBranch(path string) (string, error) {
 
 	branch := strings.TrimSpace(string(out))
 	if branch == "HEAD" {
-		cmd = exec.Command("git", "rev-parse", "--verify", "HEAD")
+		cmd = newCmd("git", "rev-parse", "--verify", "HEAD")
 		cmd.Dir = path
 		if err := cmd.Run(); err != nil {
 			return branchStateNoCommits, nil
@@ -191,18 +195,23 @@ func (cfg *Config) filterReposByBranch(repos []RepoInfo, workers int) []RepoInfo
 }
 
 func listAllBranches(ctx context.Context, root string, workers int, cfg *Config) error {
+	out := newResultWriter(cfg.Output, "branch")
 	repos, total := discoverRepos(root, workers, cfg, false)
 	if repos == nil {
-		return nil
+		return out.finish(false)
 	}
 
-	fmt.Println(StyleInfo.Render(fmt.Sprintf("Listing branches in %d repos (filtered from %d discovered)...", len(repos), total)))
+	fmt.Fprintln(cfg.infoWriter(), StyleInfo.Render(fmt.Sprintf("Listing branches in %d repos (filtered from %d discovered)...", len(repos), total)))
 
-	results := runPool(ctx, repos, workers, func(_ context.Context, r RepoInfo) BranchResult {
+	results := runPoolWith(ctx, repos, workers, newPoolOptions[BranchResult](out), func(_ context.Context, r RepoInfo) BranchResult {
 		branch, err := getBranch(r.Path)
 		return BranchResult{RelPath: r.RelPath, Branch: branch, Error: err}
 	})
 
+	if out.enabled() {
+		return out.finish(false)
+	}
+
 	branchRepos := make(map[string][]string)
 	for _, res := range results {
 		key := res.Branch
@@ -230,148 +239,216 @@ func listAllBranches(ctx context.Context, root string, workers int, cfg *Config)
 	return nil
 }
 
-func executeCommandInRepos(ctx context.Context, root, command string, workers int, cfg *Config) error {
-	repos, total := discoverRepos(root, workers, cfg, false)
-	if repos == nil {
-		return nil
-	}
-
-	args := strings.Fields(command)
+func executeCommandInRepos(ctx context.Context, root string, args []string, workers int, cfg *Config) error {
 	if len(args) == 0 {
 		return fmt.Errorf("empty command")
 	}
+	command := quoteCommandLine(args)
+	tmpl, err := parseCommandTemplate(args...)
+	if err != nil {
+		return err
+	}
+
+	out := newResultWriter(cfg.Output, "command")
+	repos, total := discoverRepos(root, workers, cfg, false)
+	if repos == nil {
+		return out.finish(false)
+	}
 
-	fmt.Println(StyleInfo.Render(fmt.Sprintf("Found %d repos (filtered from %d discovered), executing 'git %s' with %d workers...",
+	fmt.Fprintln(cfg.infoWriter(), StyleInfo.Render(fmt.Sprintf("Found %d repos (filtered from %d discovered), executing 'git %s' with %d workers...",
 		len(repos), total, command, min(workers, len(repos)))))
 
-	logManager, err := NewLogManager()
+	logManager, err := cfg.newLogManager(root, "git "+command)
 	if err != nil {
 		return fmt.Errorf("log manager: %w", err)
 	}
 
-	progress := NewProgressState(repos, fmt.Sprintf("Executing 'git %s'", command), cfg.PageSize)
+	progress := cfg.newProgress(repos, fmt.Sprintf("Executing 'git %s'", command), workers, logManager.expectedDurations(repos))
+	streamer := cfg.newOutputStreamer(progress)
 	stop := progress.start()
 
-	results := runPool(ctx, repos, workers, func(ctx context.Context, r RepoInfo) CommandResult {
+	results := runPoolWith(ctx, repos, workers, newRunOptions[CommandResult](cfg, out, progress).withLogs(logManager), func(ctx context.Context, r RepoInfo) CommandResult {
 		progress.UpdateStatus(r.RelPath, statusProcessing, "")
 
+		var vars templateVars
+		if tmpl.templated {
+			vars = cfg.templateVars(r)
+		}
+		args, renderErr := tmpl.render(vars)
+		if renderErr != nil {
+			progress.UpdateStatus(r.RelPath, statusFailed, renderErr.Error())
+			return CommandResult{RelPath: r.RelPath, Error: renderErr}
+		}
+
 		logFile, logErr := logManager.CreateLogFile(r.RelPath)
 		if logErr != nil {
 			output, retries, cmdErr := executeGitCommandWithRetry(ctx, r.Path, args...)
+			w, flush := streamer.tee(r.RelPath, io.Discard)
+			_, _ = w.Write(output)
+			flush()
 			st, msg := progressStatusFromErr(cmdErr)
 			progress.UpdateStatus(r.RelPath, st, msg)
-			return CommandResult{RelPath: r.RelPath, Output: string(output), Error: cmdErr, Retries: retries}
+			return CommandResult{RelPath: r.RelPath, Output: string(output), Error: cmdErr, ExitCode: exitCodeOf(cmdErr), Retries: retries}
 		}
-		retries, cmdErr := executeGitCommandWithRetryToFile(ctx, r.Path, logFile, args...)
+		w, flush := streamer.tee(r.RelPath, logFile)
+		retries, cmdErr := executeGitCommandWithRetryToFile(ctx, r.Path, w, args...)
+		flush()
 		_ = logFile.Close()
 		st, msg := progressStatusFromErr(cmdErr)
 		progress.UpdateStatus(r.RelPath, st, msg)
-		return CommandResult{RelPath: r.RelPath, Error: cmdErr, Retries: retries}
+		res := CommandResult{RelPath: r.RelPath, Error: cmdErr, ExitCode: exitCodeOf(cmdErr), Retries: retries}
+		if out.enabled() {
+			res.Output, _ = logManager.ReadLog(r.RelPath)
+		}
+		return res
 	})
 
-	success, failed := 0, 0
+	success, failed, cancelled := 0, 0, 0
 	for _, res := range results {
-		if res.Error != nil {
+		switch {
+		case res.Cancelled:
+			cancelled++
+		case res.Error != nil:
 			failed++
-		} else {
+		default:
 			success++
 		}
 	}
 
 	stop()
+	logManager.finish()
+
+	reportCI(cfg, "git "+command, logManager, results)
+
+	if out.enabled() {
+		reportTimings(cfg, results)
+		return out.finishRun(failed, cancelled)
+	}
 
 	fmt.Println("\n" + StyleBold.Render("--- Summary ---"))
-	fmt.Printf("Executed 'git %s' in %d repos: %s succeeded, %s failed\n",
+	fmt.Printf("Executed 'git %s' in %d repos: %s succeeded, %s failed%s\n",
 		command, success+failed,
 		StyleSuccess.Render(fmt.Sprintf("%d", success)),
-		StyleFailed.Render(fmt.Sprintf("%d", failed)))
-
-	if PromptViewLogs() {
+		StyleFailed.Render(fmt.Sprintf("%d", failed)),
+		cancelledNote(cancelled))
+	reportTimings(cfg, results)
+
+	switch {
+	case cfg.GroupOutput:
+		DisplayGroupedLogs(logManager, results)
+	case !cfg.Stream && PromptViewLogs():
 		DisplayLogs(logManager, results)
-	} else {
-		fmt.Printf("\nLogs are available at: %s\n", logManager.GetTempDir())
-		fmt.Println("You can review them later if needed.")
+	default:
+		printLogLocation(logManager)
 	}
 
-	if failed > 0 {
-		return errReposFailed
-	}
-	return nil
+	return runError(failed, cancelled)
 }
 
 func executeShellInRepos(ctx context.Context, root, command string, workers int, cfg *Config) error {
-	repos, total := discoverRepos(root, workers, cfg, false)
-	if repos == nil {
-		return nil
-	}
-
 	if strings.TrimSpace(command) == "" {
 		return fmt.Errorf("empty command")
 	}
+	tmpl, err := parseCommandTemplate(command)
+	if err != nil {
+		return err
+	}
+
+	out := newResultWriter(cfg.Output, "shell")
+	repos, total := discoverRepos(root, workers, cfg, false)
+	if repos == nil {
+		return out.finish(false)
+	}
 
-	fmt.Println(StyleInfo.Render(fmt.Sprintf("Found %d repos (filtered from %d discovered), executing '%s' with %d workers...",
+	fmt.Fprintln(cfg.infoWriter(), StyleInfo.Render(fmt.Sprintf("Found %d repos (filtered from %d discovered), executing '%s' with %d workers...",
 		len(repos), total, command, min(workers, len(repos)))))
 
-	logManager, err := NewLogManager()
+	logManager, err := cfg.newLogManager(root, command)
 	if err != nil {
 		return fmt.Errorf("log manager: %w", err)
 	}
 
-	progress := NewProgressState(repos, fmt.Sprintf("Executing '%s'", command), cfg.PageSize)
+	progress := cfg.newProgress(repos, fmt.Sprintf("Executing '%s'", command), workers, logManager.expectedDurations(repos))
+	streamer := cfg.newOutputStreamer(progress)
 	stop := progress.start()
 
-	results := runPool(ctx, repos, workers, func(ctx context.Context, r RepoInfo) CommandResult {
+	results := runPoolWith(ctx, repos, workers, newRunOptions[CommandResult](cfg, out, progress).withLogs(logManager), func(ctx context.Context, r RepoInfo) CommandResult {
 		progress.UpdateStatus(r.RelPath, statusProcessing, "")
 
+		vars := cfg.templateVars(r)
+		rendered, renderErr := tmpl.render(vars.shellQuoted())
+		if renderErr != nil {
+			progress.UpdateStatus(r.RelPath, statusFailed, renderErr.Error())
+			return CommandResult{RelPath: r.RelPath, Error: renderErr}
+		}
+		command := rendered[0]
+
 		logFile, logErr := logManager.CreateLogFile(r.RelPath)
 		if logErr != nil {
 			cmdCtx, cancel := context.WithTimeout(ctx, gitCommandTimeout)
 			defer cancel()
-			var cmd *exec.Cmd
-			if runtime.GOOS == "windows" {
-				cmd = exec.CommandContext(cmdCtx, "cmd", "/c", command)
-			} else {
-				cmd = exec.CommandContext(cmdCtx, "sh", "-c", command)
-			}
+			cmd := newShellCmdContext(cmdCtx, command)
 			cmd.Dir = r.Path
+			cmd.Env = append(os.Environ(), vars.env()...)
 			output, cmdErr := cmd.CombinedOutput()
+			w, flush := streamer.tee(r.RelPath, io.Discard)
+			_, _ = w.Write(output)
+			flush()
 			st, msg := progressStatusFromErr(cmdErr)
 			progress.UpdateStatus(r.RelPath, st, msg)
-			return CommandResult{RelPath: r.RelPath, Output: string(output), Error: cmdErr}
+			return CommandResult{RelPath: r.RelPath, Output: string(output), Error: cmdErr, ExitCode: exitCodeOf(cmdErr)}
 		}
-		cmdErr := executeShellCommandToFile(ctx, r.Path, logFile, command)
+		w, flush := streamer.tee(r.RelPath, logFile)
+		cmdErr := executeShellCommandToFile(ctx, r.Path, w, command, vars.env())
+		flush()
 		_ = logFile.Close()
 		st, msg := progressStatusFromErr(cmdErr)
 		progress.UpdateStatus(r.RelPath, st, msg)
-		return CommandResult{RelPath: r.RelPath, Error: cmdErr}
+		res := CommandResult{RelPath: r.RelPath, Error: cmdErr, ExitCode: exitCodeOf(cmdErr)}
+		if out.enabled() {
+			res.Output, _ = logManager.ReadLog(r.RelPath)
+		}
+		return res
 	})
 
-	success, failed := 0, 0
+	success, failed, cancelled := 0, 0, 0
 	for _, res := range results {
-		if res.Error != nil {
+		switch {
+		case res.Cancelled:
+			cancelled++
+		case res.Error != nil:
 			failed++
-		} else {
+		default:
 			success++
 		}
 	}
 
 	stop()
+	logManager.finish()
+
+	reportCI(cfg, command, logManager, results)
+
+	if out.enabled() {
+		reportTimings(cfg, results)
+		return out.finishRun(failed, cancelled)
+	}
 
 	fmt.Println("\n" + StyleBold.Render("--- Summary ---"))
-	fmt.Printf("Executed '%s' in %d repos: %s succeeded, %s failed\n",
+	fmt.Printf("Executed '%s' in %d repos: %s succeeded, %s failed%s\n",
 		command, success+failed,
 		StyleSuccess.Render(fmt.Sprintf("%d", success)),
-		StyleFailed.Render(fmt.Sprintf("%d", failed)))
-
-	if PromptViewLogs() {
+		StyleFailed.Render(fmt.Sprintf("%d", failed)),
+		cancelledNote(cancelled))
+	reportTimings(cfg, results)
+
+	switch {
+	case cfg.GroupOutput:
+		DisplayGroupedLogs(logManager, results)
+	case !cfg.Stream && PromptViewLogs():
 		DisplayLogs(logManager, results)
-	} else {
-		fmt.Printf("\nLogs are available at: %s\n", logManager.GetTempDir())
-		fmt.Println("You can review them later if needed.")
+	default:
+		printLogLocation(logManager)
 	}
 
-	if failed > 0 {
-		return errReposFailed
-	}
-	return nil
+	return runError(failed, cancelled)
 }
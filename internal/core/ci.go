@@ -0,0 +1,225 @@
+package core
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ciGitHub = "github"
+	ciGitLab = "gitlab"
+)
+
+func validateCIFormat(format string) error {
+	switch format {
+	case "", ciGitHub, ciGitLab:
+		return nil
+	}
+	return fmt.Errorf("invalid CI format %q (want %s or %s)", format, ciGitHub, ciGitLab)
+}
+
+type ciEntry struct {
+	relPath string
+	state   string
+	message string
+	level   string
+}
+
+// ciReport turns a run's outcomes into CI annotations, one collapsible log
+// section per flagged repo, and a GitHub job summary. It is nil without
+// --ci-format, and its methods do nothing then.
+type ciReport struct {
+	format  string
+	title   string
+	w       io.Writer
+	entries []ciEntry
+}
+
+func (cfg *Config) newCIReport(title string) *ciReport {
+	if cfg.CIFormat == "" {
+		return nil
+	}
+	return &ciReport{format: cfg.CIFormat, title: title, w: cfg.infoWriter()}
+}
+
+// reportCI writes the --ci-format annotations and the --junit report for a
+// run's results. lm may be nil for operations without logs.
+func reportCI[R repoResult](cfg *Config, title string, lm *LogManager, results []R) {
+	ci := cfg.newCIReport(title)
+	junit := cfg.newJUnitReport(title)
+	for _, res := range results {
+		ci.add(res)
+		junit.add(res)
+	}
+	ci.finish(lm)
+	if err := junit.finish(lm); err != nil {
+		fmt.Fprintln(os.Stderr, StyleFailed.Render(fmt.Sprintf("Warning: %v", err)))
+	}
+}
+
+// add flags failed repos as errors and skipped ones as warnings.
+func (c *ciReport) add(res repoResult) {
+	if c == nil {
+		return
+	}
+	state, msg := res.outcome()
+	level := ""
+	switch state {
+	case statusFailed:
+		level = "error"
+	case statusSkipped:
+		level = "warning"
+	}
+	c.entries = append(c.entries, ciEntry{relPath: res.repoPath(), state: state, message: msg, level: level})
+}
+
+func (c *ciReport) warn(relPath, state, message string) {
+	if c == nil {
+		return
+	}
+	c.entries = append(c.entries, ciEntry{relPath: relPath, state: state, message: message, level: "warning"})
+}
+
+// finish writes the annotations, then the job summary when
+// $GITHUB_STEP_SUMMARY is set. lm may be nil for operations without logs.
+func (c *ciReport) finish(lm *LogManager) {
+	if c == nil {
+		return
+	}
+	sort.SliceStable(c.entries, func(i, j int) bool {
+		a, b := c.entries[i], c.entries[j]
+		if ciLevelRank(a.level) != ciLevelRank(b.level) {
+			return ciLevelRank(a.level) < ciLevelRank(b.level)
+		}
+		return a.relPath < b.relPath
+	})
+	for _, e := range c.entries {
+		if e.level == "" {
+			continue
+		}
+		body := e.message
+		if lm != nil {
+			if content, err := lm.ReadLog(e.relPath); err == nil && strings.TrimSpace(content) != "" {
+				body = strings.TrimRight(content, "\n")
+			}
+		}
+		if c.format == ciGitLab {
+			c.writeGitLab(e, body)
+		} else {
+			c.writeGitHub(e, body)
+		}
+	}
+
+	if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := c.writeStepSummary(path); err != nil {
+			fmt.Fprintln(os.Stderr, StyleFailed.Render(fmt.Sprintf("Warning: could not write job summary: %v", err)))
+		}
+	}
+}
+
+func (e ciEntry) summary() string {
+	if e.message == "" {
+		return e.state
+	}
+	return e.message
+}
+
+func ciLevelRank(level string) int {
+	switch level {
+	case "error":
+		return 0
+	case "warning":
+		return 1
+	}
+	return 2
+}
+
+var ciEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+var ciPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// writeGitHub stops workflow command processing around the log, so a line
+// in it that starts with "::" is printed rather than run.
+func (c *ciReport) writeGitHub(e ciEntry, body string) {
+	fmt.Fprintf(c.w, "::%s title=%s::%s\n", e.level, ciPropertyEscaper.Replace(e.relPath), ciEscaper.Replace(e.summary()))
+	fmt.Fprintf(c.w, "::group::%s (%s)\n", e.relPath, e.state)
+	if body != "" {
+		token := rand.Text()
+		fmt.Fprintf(c.w, "::stop-commands::%s\n", token)
+		fmt.Fprintln(c.w, body)
+		fmt.Fprintf(c.w, "::%s::\n", token)
+	}
+	fmt.Fprintln(c.w, "::endgroup::")
+}
+
+var gitlabSectionName = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// writeGitLab prints a colored error or warning line and a collapsed
+// section with the repo's log; GitLab has no annotation syntax.
+func (c *ciReport) writeGitLab(e ciEntry, body string) {
+	color, label := "33", "WARNING"
+	if e.level == "error" {
+		color, label = "31", "ERROR"
+	}
+	fmt.Fprintf(c.w, "\x1b[%s;1m%s: %s: %s\x1b[0m\n", color, label, e.relPath, strings.ReplaceAll(e.summary(), "\n", " "))
+
+	name := "gb_" + gitlabSectionName.ReplaceAllString(e.relPath, "_")
+	fmt.Fprintf(c.w, "\x1b[0Ksection_start:%d:%s[collapsed=true]\r\x1b[0K%s (%s)\n", time.Now().Unix(), name, e.relPath, e.state)
+	if body != "" {
+		fmt.Fprintln(c.w, body)
+	}
+	fmt.Fprintf(c.w, "\x1b[0Ksection_end:%d:%s\r\x1b[0K\n", time.Now().Unix(), name)
+}
+
+func (c *ciReport) writeStepSummary(path string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	var extra []string
+	for _, e := range c.entries {
+		switch e.state {
+		case statusCompleted, statusFailed, statusSkipped, statusCancelled:
+		default:
+			if counts[e.state] == 0 {
+				extra = append(extra, e.state)
+			}
+		}
+		counts[e.state]++
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### gb: %s\n\n", c.title)
+	fmt.Fprintf(&sb, "%d repos: %d ok, %d failed, %d skipped", len(c.entries), counts[statusCompleted], counts[statusFailed], counts[statusSkipped])
+	for _, state := range append([]string{statusCancelled}, extra...) {
+		if n := counts[state]; n > 0 {
+			fmt.Fprintf(&sb, ", %d %s", n, state)
+		}
+	}
+	sb.WriteString("\n\n| Repo | Status | Details |\n|------|--------|---------|\n")
+	for _, e := range c.entries {
+		icon := runStatusIcon(e.state)
+		if e.level == "warning" && e.state != statusSkipped {
+			icon = "⚠️"
+		}
+		fmt.Fprintf(&sb, "| %s | %s %s | %s |\n", markdownCell(e.relPath), icon, e.state, markdownCell(e.message))
+	}
+	sb.WriteString("\n")
+
+	_, err = f.WriteString(sb.String())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(strings.TrimSpace(s), "\n", "<br>")
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIReportGitHub(t *testing.T) {
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+
+	lm, err := NewLogManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lm.Cleanup() }()
+	f, err := lm.CreateLogFile("services/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("fatal: could not read\n::error::not a command\n")
+	_ = f.Close()
+
+	var buf bytes.Buffer
+	ci := &ciReport{format: ciGitHub, title: "git fetch", w: &buf}
+	ci.add(CommandResult{RelPath: "services/api"})
+	ci.add(SwitchResult{RelPath: "tools/cli", Skipped: true, Error: "branch not found"})
+	ci.add(CommandResult{RelPath: "services/web", Error: errors.New("exit status 128\ndetails")})
+	ci.finish(lm)
+
+	out := buf.String()
+	lines := strings.Split(out, "\n")
+	if lines[0] != "::error title=services/web::exit status 128%0Adetails" || lines[1] != "::group::services/web (failed)" {
+		t.Errorf("expected the failure annotation and group first, got:\n%s", out)
+	}
+	if !strings.HasPrefix(lines[2], "::stop-commands::") || lines[4] != "::error::not a command" || lines[5] != "::"+strings.TrimPrefix(lines[2], "::stop-commands::")+"::" {
+		t.Errorf("expected the log to be shielded from workflow commands, got:\n%s", out)
+	}
+	if !strings.Contains(out, "::warning title=tools/cli::branch not found\n::group::tools/cli (skipped)\n") {
+		t.Errorf("expected a warning for the skipped repo, got:\n%s", out)
+	}
+	if strings.Contains(out, "services/api") {
+		t.Errorf("expected no annotation for the successful repo, got:\n%s", out)
+	}
+
+	data, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"### gb: git fetch", "3 repos: 1 ok, 1 failed, 1 skipped", "| services/web | ❌ failed | exit status 128<br>details |", "| services/api | ✅ completed |  |"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in the job summary:\n%s", want, data)
+		}
+	}
+}
+
+func TestCIReportGitLab(t *testing.T) {
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	var buf bytes.Buffer
+	ci := &ciReport{format: ciGitLab, title: "divergence vs origin/main", w: &buf}
+	ci.warn("services/api", "behind", "4 behind, 0 ahead of origin/main")
+	ci.finish(nil)
+
+	out := buf.String()
+	if !strings.Contains(out, "WARNING: services/api: 4 behind, 0 ahead of origin/main") {
+		t.Errorf("expected a warning line, got %q", out)
+	}
+	if !strings.Contains(out, ":gb_services_api[collapsed=true]\r\x1b[0Kservices/api (behind)\n") || !strings.Contains(out, ":gb_services_api\r\x1b[0K\n") {
+		t.Errorf("expected a collapsed section, got %q", out)
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"status", []string{"status"}},
+		{"  log   --oneline\t-5 ", []string{"log", "--oneline", "-5"}},
+		{`commit -m "fix typo"`, []string{"commit", "-m", "fix typo"}},
+		{`log --format="%h %s"`, []string{"log", "--format=%h %s"}},
+		{`log --format='%h "%s"'`, []string{"log", `--format=%h "%s"`}},
+		{`commit -m it\'s`, []string{"commit", "-m", "it's"}},
+		{`commit -m "say \"hi\" \n"`, []string{"commit", "-m", `say "hi" \n`}},
+		{`grep a\ b`, []string{"grep", "a b"}},
+		{`commit -m ""`, []string{"commit", "-m", ""}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.input)
+		if err != nil {
+			t.Errorf("splitCommandLine(%q): unexpected error %v", tt.input, err)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("splitCommandLine(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSplitCommandLineErrors(t *testing.T) {
+	for _, input := range []string{`commit -m "fix`, `log --format='%h`, `status \`} {
+		if _, err := splitCommandLine(input); err == nil {
+			t.Errorf("splitCommandLine(%q): expected error", input)
+		}
+	}
+}
+
+func TestQuoteCommandLineRoundTrip(t *testing.T) {
+	args := []string{"log", "--format=%h %s", "it's", ""}
+	got, err := splitCommandLine(quoteCommandLine(args))
+	if err != nil || strings.Join(got, "|") != strings.Join(args, "|") {
+		t.Errorf("round trip of %q gave %q (%v)", args, got, err)
+	}
+}
+
+func TestRunUnbalancedQuoteTouchesNoRepo(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo1")
+	createGitRepo(t, repoDir)
+
+	oldDir, _ := os.Getwd()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(oldDir) }()
+
+	err := Run(context.Background(), []string{"-c", `commit --allow-empty -m "oops`})
+	if err == nil || !strings.Contains(err.Error(), "unbalanced") {
+		t.Fatalf("expected unbalanced quote error, got %v", err)
+	}
+	if count := strings.TrimSpace(string(runCmdOutput(t, repoDir, "git", "rev-list", "--count", "HEAD"))); count != "1" {
+		t.Errorf("expected no new commit, got %s commits", count)
+	}
+}
+
+func TestRunDoubleDashPassesRawArgv(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo1")
+	createGitRepo(t, repoDir)
+
+	oldDir, _ := os.Getwd()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(oldDir) }()
+
+	err := runQuiet(t, func() error {
+		return Run(context.Background(), []string{"-o", "json", "--", "commit", "--allow-empty", "-m", "two words"})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg := strings.TrimSpace(string(runCmdOutput(t, repoDir, "git", "log", "-1", "--format=%s"))); msg != "two words" {
+		t.Errorf("expected commit message %q, got %q", "two words", msg)
+	}
+}
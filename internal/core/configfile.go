@@ -0,0 +1,213 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+var workspaceConfigNames = []string{".gb.yaml", ".gb.yml"}
+
+type fileConfig struct {
+	Workers          *int                `yaml:"workers"`
+	PageSize         *int                `yaml:"pageSize"`
+	Remote           string              `yaml:"remote"`
+	ExcludeDirs      []string            `yaml:"excludeDirs"`
+	IncludeDirs      []string            `yaml:"includeDirs"`
+	ExcludeBranches  []string            `yaml:"excludeBranches"`
+	IncludeBranches  []string            `yaml:"includeBranches"`
+	IncludeWorktrees *bool               `yaml:"includeWorktrees"`
+	WorktreeBase     string              `yaml:"worktreeBase"`
+	Manifest         string              `yaml:"manifest"`
+	Repos            []repoOverride      `yaml:"repos"`
+	Groups           map[string][]string `yaml:"groups"`
+}
+
+type repoOverride struct {
+	Path          string `yaml:"path"`
+	Remote        string `yaml:"remote"`
+	DefaultBranch string `yaml:"defaultBranch"`
+	WorktreeBase  string `yaml:"worktreeBase"`
+}
+
+func userConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		var err error
+		if dir, err = os.UserConfigDir(); err != nil {
+			return ""
+		}
+	}
+	return filepath.Join(dir, "gb", "config.yaml")
+}
+
+func findWorkspaceConfig(dir string) string {
+	dir = filepath.Clean(dir)
+	for {
+		for _, name := range workspaceConfigNames {
+			candidate := filepath.Join(dir, name)
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func readFileConfig(p string) (*fileConfig, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &fileConfig{}, nil
+		}
+		return nil, err
+	}
+	fc := &fileConfig{}
+	if err := yaml.Unmarshal(data, fc); err != nil {
+		return nil, fmt.Errorf("config %s: %w", p, err)
+	}
+	for _, o := range fc.Repos {
+		if o.Path == "" {
+			return nil, fmt.Errorf("config %s: repo override without path", p)
+		}
+		if _, err := path.Match(o.Path, ""); err != nil {
+			return nil, fmt.Errorf("config %s: invalid repo pattern %q: %w", p, o.Path, err)
+		}
+	}
+	if fc.Manifest != "" && !filepath.IsAbs(fc.Manifest) {
+		fc.Manifest = filepath.Join(filepath.Dir(p), fc.Manifest)
+	}
+	return fc, nil
+}
+
+func loadFileConfig(root string) (*fileConfig, error) {
+	merged := &fileConfig{}
+	if p := userConfigPath(); p != "" {
+		userCfg, err := readFileConfig(p)
+		if err != nil {
+			return nil, err
+		}
+		merged = userCfg
+	}
+	if p := findWorkspaceConfig(root); p != "" {
+		wsCfg, err := readFileConfig(p)
+		if err != nil {
+			return nil, err
+		}
+		merged = merged.merge(wsCfg)
+	}
+	return merged, nil
+}
+
+// merge layers other on top of fc; repo overrides from other are matched first.
+func (fc *fileConfig) merge(other *fileConfig) *fileConfig {
+	out := *fc
+	if other.Workers != nil {
+		out.Workers = other.Workers
+	}
+	if other.PageSize != nil {
+		out.PageSize = other.PageSize
+	}
+	if other.Remote != "" {
+		out.Remote = other.Remote
+	}
+	if other.ExcludeDirs != nil {
+		out.ExcludeDirs = other.ExcludeDirs
+	}
+	if other.IncludeDirs != nil {
+		out.IncludeDirs = other.IncludeDirs
+	}
+	if other.ExcludeBranches != nil {
+		out.ExcludeBranches = other.ExcludeBranches
+	}
+	if other.IncludeBranches != nil {
+		out.IncludeBranches = other.IncludeBranches
+	}
+	if other.IncludeWorktrees != nil {
+		out.IncludeWorktrees = other.IncludeWorktrees
+	}
+	if other.WorktreeBase != "" {
+		out.WorktreeBase = other.WorktreeBase
+	}
+	if other.Manifest != "" {
+		out.Manifest = other.Manifest
+	}
+	out.Repos = append(append([]repoOverride{}, other.Repos...), fc.Repos...)
+	if len(other.Groups) > 0 {
+		out.Groups = make(map[string][]string, len(fc.Groups)+len(other.Groups))
+		for name, members := range fc.Groups {
+			out.Groups[name] = members
+		}
+		for name, members := range other.Groups {
+			out.Groups[name] = members
+		}
+	}
+	return &out
+}
+
+func (cfg *Config) repoOverride(relPath string) repoOverride {
+	slashPath := filepath.ToSlash(filepath.Clean(relPath))
+	for _, o := range cfg.overrides {
+		if o.Path == slashPath {
+			return o
+		}
+	}
+	for _, o := range cfg.overrides {
+		if hasGlobMeta(o.Path) && matchesGlob(o.Path, slashPath) {
+			return o
+		}
+	}
+	return repoOverride{}
+}
+
+func (cfg *Config) remoteFor(relPath string) string {
+	if !cfg.remoteFromFlag {
+		if o := cfg.repoOverride(relPath); o.Remote != "" {
+			return o.Remote
+		}
+		if cfg.manifest != nil {
+			if mr, ok := cfg.manifest.find(relPath); ok {
+				return mr.Remotes[0].Name
+			}
+		}
+	}
+	return cfg.Remote
+}
+
+// targetFor returns the branch to switch or reset to: the explicit one if
+// given, otherwise the repo's manifest revision or configured default branch.
+func (cfg *Config) targetFor(relPath, explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+	if cfg.manifest != nil {
+		if mr, ok := cfg.manifest.find(relPath); ok && mr.Branch != "" {
+			return mr.Branch
+		}
+	}
+	return cfg.repoOverride(relPath).DefaultBranch
+}
+
+func (cfg *Config) worktreeBaseFor(relPath, explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+	o := cfg.repoOverride(relPath)
+	switch {
+	case o.WorktreeBase != "":
+		return o.WorktreeBase
+	case o.DefaultBranch != "":
+		return o.DefaultBranch
+	case cfg.worktreeBase != "":
+		return cfg.worktreeBase
+	}
+	return "master"
+}
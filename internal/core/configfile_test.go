@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindWorkspaceConfigWalksUp(t *testing.T) {
+	tmpDir := t.TempDir()
+	nested := filepath.Join(tmpDir, "a", "b")
+	createDir(t, nested)
+	writeFile(t, tmpDir, ".gb.yaml", "workers: 5\n")
+
+	got := findWorkspaceConfig(nested)
+	if got != filepath.Join(tmpDir, ".gb.yaml") {
+		t.Errorf("expected config in %s, got %q", tmpDir, got)
+	}
+
+	writeFile(t, filepath.Join(tmpDir, "a"), ".gb.yml", "workers: 7\n")
+	got = findWorkspaceConfig(nested)
+	if got != filepath.Join(tmpDir, "a", ".gb.yml") {
+		t.Errorf("expected nearest config to win, got %q", got)
+	}
+}
+
+func TestLoadFileConfigMergesUserAndWorkspace(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	createDir(t, filepath.Join(xdg, "gb"))
+	writeFile(t, filepath.Join(xdg, "gb"), "config.yaml", `
+workers: 10
+remote: upstream
+excludeDirs: [legacy]
+repos:
+  - path: "OCA/*"
+    remote: oca
+`)
+
+	ws := t.TempDir()
+	writeFile(t, ws, ".gb.yaml", `
+workers: 40
+repos:
+  - path: OCA/project
+    defaultBranch: "15.0"
+`)
+
+	fc, err := loadFileConfig(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fc.Workers == nil || *fc.Workers != 40 {
+		t.Errorf("expected workspace workers to win, got %v", fc.Workers)
+	}
+	if fc.Remote != "upstream" {
+		t.Errorf("expected user remote to be kept, got %q", fc.Remote)
+	}
+	if len(fc.ExcludeDirs) != 1 || fc.ExcludeDirs[0] != "legacy" {
+		t.Errorf("expected user excludeDirs to be kept, got %v", fc.ExcludeDirs)
+	}
+	if len(fc.Repos) != 2 || fc.Repos[0].Path != "OCA/project" {
+		t.Errorf("expected workspace overrides first, got %+v", fc.Repos)
+	}
+}
+
+func TestReadFileConfigInvalid(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "bad.yaml", "repos:\n  - path: \"feat[\"\n")
+	if _, err := readFileConfig(filepath.Join(dir, "bad.yaml")); err == nil {
+		t.Error("expected error for invalid repo pattern")
+	}
+
+	writeFile(t, dir, "nopath.yaml", "repos:\n  - remote: upstream\n")
+	if _, err := readFileConfig(filepath.Join(dir, "nopath.yaml")); err == nil {
+		t.Error("expected error for override without path")
+	}
+
+	fc, err := readFileConfig(filepath.Join(dir, "missing.yaml"))
+	if err != nil || fc == nil {
+		t.Errorf("expected empty config for missing file, got %v, %v", fc, err)
+	}
+}
+
+func TestRepoOverrideResolution(t *testing.T) {
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	cfg.overrides = []repoOverride{
+		{Path: "OCA/*", Remote: "oca", WorktreeBase: "16.0"},
+		{Path: "OCA/project", DefaultBranch: "15.0"},
+	}
+	cfg.worktreeBase = "develop"
+
+	if got := cfg.remoteFor(filepath.Join("OCA", "survey")); got != "oca" {
+		t.Errorf("expected glob override remote, got %q", got)
+	}
+	if got := cfg.remoteFor(filepath.Join("OCA", "project")); got != "origin" {
+		t.Errorf("expected exact override without remote to fall back, got %q", got)
+	}
+	if got := cfg.worktreeBaseFor(filepath.Join("OCA", "project"), ""); got != "15.0" {
+		t.Errorf("expected defaultBranch as worktree base, got %q", got)
+	}
+	if got := cfg.worktreeBaseFor(filepath.Join("OCA", "survey"), ""); got != "16.0" {
+		t.Errorf("expected override worktreeBase, got %q", got)
+	}
+	if got := cfg.worktreeBaseFor("other", ""); got != "develop" {
+		t.Errorf("expected config-level worktreeBase, got %q", got)
+	}
+	if got := cfg.worktreeBaseFor("other", "main"); got != "main" {
+		t.Errorf("expected explicit base to win, got %q", got)
+	}
+
+	cfg.remoteFromFlag = true
+	if got := cfg.remoteFor(filepath.Join("OCA", "survey")); got != "origin" {
+		t.Errorf("expected -r flag to win over overrides, got %q", got)
+	}
+}
+
+func TestRunHonoursWorkspaceConfig(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	createGitRepo(t, filepath.Join(tmpDir, "keep"))
+	createGitRepo(t, filepath.Join(tmpDir, "legacy-app"))
+	writeFile(t, tmpDir, ".gb.yaml", "excludeDirs: [\"legacy-*\"]\n")
+
+	oldDir, _ := os.Getwd()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Skip("cannot change directory:", err)
+	}
+	defer func() { _ = os.Chdir(oldDir) }()
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	runErr := Run(context.Background(), []string{"-l"})
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+	outBytes, _ := io.ReadAll(r)
+	output := string(outBytes)
+
+	if runErr != nil {
+		t.Fatalf("Run: %v", runErr)
+	}
+	if !strings.Contains(output, "keep") {
+		t.Errorf("expected keep repo in output, got: %s", output)
+	}
+	if strings.Contains(output, "legacy-app") {
+		t.Errorf("expected legacy-app excluded by config, got: %s", output)
+	}
+}
@@ -9,9 +9,24 @@ import (
 	"testing"
 )
 
+// TestMain points the state, config and home directories at a temp dir, so
+// tests neither read the developer's gb or git config nor write to it.
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "gb-test-")
+	if err != nil {
+		panic(err)
+	}
+	_ = os.Setenv("HOME", home)
+	_ = os.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	_ = os.Setenv("XDG_STATE_HOME", filepath.Join(home, ".local", "state"))
+	code := m.Run()
+	_ = os.RemoveAll(home)
+	os.Exit(code)
+}
+
 func mustConfig(t *testing.T, excludeDirs, includeDirs, excludeBranches, includeBranches []string, pageSize int, includeWorktrees bool, remote string) *Config {
 	t.Helper()
-	cfg, err := newConfig(excludeDirs, includeDirs, excludeBranches, includeBranches, pageSize, includeWorktrees, remote)
+	cfg, err := newConfig(excludeDirs, includeDirs, excludeBranches, includeBranches, pageSize, includeWorktrees, remote, nil)
 	if err != nil {
 		t.Fatalf("newConfig: %v", err)
 	}
@@ -195,7 +210,7 @@ func TestExecuteCommandInRepos(t *testing.T) {
 	os.Stdout = w
 
 	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
-	executeCommandInRepos(context.Background(), tmpDir, "status", 2, cfg) //nolint:errcheck
+	executeCommandInRepos(context.Background(), tmpDir, []string{"status"}, 2, cfg) //nolint:errcheck
 
 	_ = w.Close()
 	os.Stdout = oldStdout
@@ -962,23 +977,32 @@ func TestShouldExecuteInBranch(t *testing.T) {
 }
 
 func TestNewConfigInvalidPattern(t *testing.T) {
-	_, err := newConfig(nil, []string{"feat["}, nil, nil, 20, false, "origin")
+	_, err := newConfig(nil, []string{"feat["}, nil, nil, 20, false, "origin", nil)
 	if err == nil {
 		t.Error("expected error for malformed include pattern, got nil")
 	}
 
-	_, err = newConfig([]string{"build["}, nil, nil, nil, 20, false, "origin")
+	_, err = newConfig([]string{"build["}, nil, nil, nil, 20, false, "origin", nil)
 	if err == nil {
 		t.Error("expected error for malformed exclude pattern, got nil")
 	}
 
-	_, err = newConfig(nil, nil, nil, []string{"release/["}, 20, false, "origin")
+	_, err = newConfig(nil, nil, nil, []string{"release/["}, 20, false, "origin", nil)
 	if err == nil {
 		t.Error("expected error for malformed include branch pattern, got nil")
 	}
 
-	_, err = newConfig(nil, nil, []string{"feat["}, nil, 20, false, "origin")
+	_, err = newConfig(nil, nil, []string{"feat["}, nil, 20, false, "origin", nil)
 	if err == nil {
 		t.Error("expected error for malformed exclude branch pattern, got nil")
 	}
 }
+
+func TestReorderArgsKeepsPositionalAfterBoolFlags(t *testing.T) {
+	for _, flag := range []string{"--stream", "-stream", "-group-output", "-fail-fast", "-retry-failed", "-timings", "-track", "-list", "-y", "--yes", "--force"} {
+		got := reorderArgs([]string{flag, "feature", "-w", "4"})
+		if want := flag + "|-w|4|feature"; strings.Join(got, "|") != want {
+			t.Errorf("%s: expected %q, got %q", flag, want, strings.Join(got, "|"))
+		}
+	}
+}
@@ -3,26 +3,26 @@ package core
 import (
 	"context"
 	"fmt"
-	"os/exec"
 	"sort"
 	"strconv"
 	"strings"
 )
 
 type DivergeResult struct {
-	RelPath     string
-	Branch      string
-	UpstreamRef string
-	Ahead       int
-	Behind      int
-	Success     bool
-	Skipped     bool
-	SkipReason  string
-	Error       string
+	RelPath     string `json:"relPath"`
+	Branch      string `json:"branch"`
+	UpstreamRef string `json:"upstreamRef"`
+	Ahead       int    `json:"ahead"`
+	Behind      int    `json:"behind"`
+	Success     bool   `json:"success"`
+	Skipped     bool   `json:"skipped"`
+	SkipReason  string `json:"skipReason"`
+	Error       string `json:"error"`
+	repoTiming
 }
 
 func getTrackingRef(dir string) (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}")
+	cmd := newCmd("git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}")
 	cmd.Dir = dir
 	out, err := cmd.Output()
 	if err != nil {
@@ -57,13 +57,13 @@ func processSingleDiverge(repo RepoInfo, ref, defaultRemote string) DivergeResul
 		remoteRef = remote + "/" + resolvedBranch
 	}
 
-	verifyCmd := exec.Command("git", "rev-parse", "--verify", remoteRef)
+	verifyCmd := newCmd("git", "rev-parse", "--verify", remoteRef)
 	verifyCmd.Dir = repo.Path
 	if verifyCmd.Run() != nil {
 		return DivergeResult{RelPath: repo.RelPath, Branch: branch, UpstreamRef: remoteRef, Skipped: true, SkipReason: "remote ref not found"}
 	}
 
-	cmd := exec.Command("git", "rev-list", "--left-right", "--count", "HEAD..."+remoteRef)
+	cmd := newCmd("git", "rev-list", "--left-right", "--count", "HEAD..."+remoteRef)
 	cmd.Dir = repo.Path
 	out, err := cmd.Output()
 	if err != nil {
@@ -89,9 +89,10 @@ func processSingleDiverge(repo RepoInfo, ref, defaultRemote string) DivergeResul
 }
 
 func checkDiverge(ctx context.Context, root, ref string, workers int, cfg *Config) error {
+	out := newResultWriter(cfg.Output, "diverge")
 	repos, total := discoverRepos(root, workers, cfg, false)
 	if repos == nil {
-		return nil
+		return out.finish(false)
 	}
 
 	trackingMode := ref == ""
@@ -102,14 +103,28 @@ func checkDiverge(ctx context.Context, root, ref string, workers int, cfg *Confi
 		displayRef = cfg.Remote + "/" + ref
 	}
 
-	fmt.Println(StyleInfo.Render(fmt.Sprintf(
+	fmt.Fprintln(cfg.infoWriter(), StyleInfo.Render(fmt.Sprintf(
 		"Found %d repos (filtered from %d discovered), checking divergence vs %s with %d workers...",
 		len(repos), total, displayRef, min(workers, len(repos)))))
 
-	results := runPool(ctx, repos, workers, func(_ context.Context, r RepoInfo) DivergeResult {
-		return processSingleDiverge(r, ref, cfg.Remote)
+	results := runPoolWith(ctx, repos, workers, newPoolOptions[DivergeResult](out), func(_ context.Context, r RepoInfo) DivergeResult {
+		return processSingleDiverge(r, ref, cfg.remoteFor(r.RelPath))
 	})
 
+	ci := cfg.newCIReport("divergence vs " + displayRef)
+	for _, r := range results {
+		if r.Success && r.Behind > cfg.CIMaxBehind {
+			ci.warn(r.RelPath, "behind", fmt.Sprintf("%d behind, %d ahead of %s", r.Behind, r.Ahead, r.UpstreamRef))
+			continue
+		}
+		ci.add(r)
+	}
+	ci.finish(nil)
+
+	if out.enabled() {
+		return out.finish(false)
+	}
+
 	sort.Slice(results, func(i, j int) bool { return results[i].RelPath < results[j].RelPath })
 
 	const noTrackingPlaceholder = "(no tracking)"
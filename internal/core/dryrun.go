@@ -0,0 +1,122 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+type RepoPlan struct {
+	RelPath  string   `json:"relPath"`
+	Commands []string `json:"commands"`
+	Skipped  bool     `json:"skipped"`
+	Reason   string   `json:"reason"`
+	Error    string   `json:"error"`
+}
+
+func (p *RepoPlan) add(args ...string) {
+	p.Commands = append(p.Commands, "git "+strings.Join(args, " "))
+}
+
+func (p RepoPlan) repoPath() string { return p.RelPath }
+
+func (p RepoPlan) outcome() (string, string) {
+	switch {
+	case p.Error != "":
+		return statusFailed, p.Error
+	case p.Skipped:
+		return statusSkipped, p.Reason
+	}
+	return statusCompleted, ""
+}
+
+// gitStep runs a mutating git command, or only records it when plan is non-nil.
+func gitStep(ctx context.Context, dir string, logFile *os.File, plan *RepoPlan, args ...string) error {
+	if plan != nil {
+		plan.add(args...)
+		return nil
+	}
+	cmd := newCmdContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if logFile != nil {
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+	}
+	return cmd.Run()
+}
+
+func gitStepWithRetry(ctx context.Context, dir string, plan *RepoPlan, args ...string) ([]byte, error) {
+	if plan != nil {
+		plan.add(args...)
+		return nil, nil
+	}
+	out, _, err := executeGitCommandWithRetry(ctx, dir, args...)
+	return out, err
+}
+
+func planRepos(ctx context.Context, repos []RepoInfo, workers int, cfg *Config, opDesc string, process func(context.Context, RepoInfo, *RepoPlan) repoResult) error {
+	out := newResultWriter(cfg.Output, "plan")
+	fmt.Fprintln(cfg.infoWriter(), StyleInfo.Render(fmt.Sprintf("Dry run: planning '%s' across %d repos...", opDesc, len(repos))))
+
+	plans := runPoolWith(ctx, repos, workers, newPoolOptions[RepoPlan](out), func(ctx context.Context, r RepoInfo) RepoPlan {
+		plan := &RepoPlan{RelPath: r.RelPath, Commands: []string{}}
+		state, msg := process(ctx, r, plan).outcome()
+		switch state {
+		case statusSkipped:
+			plan.Skipped, plan.Reason = true, msg
+		case statusFailed:
+			plan.Error = msg
+		}
+		return *plan
+	})
+
+	var changes, skipped, failed int
+	for _, p := range plans {
+		switch state, _ := p.outcome(); state {
+		case statusSkipped:
+			skipped++
+		case statusFailed:
+			failed++
+		default:
+			changes++
+		}
+	}
+
+	if out.enabled() {
+		return out.finish(failed > 0)
+	}
+
+	sort.Slice(plans, func(i, j int) bool { return plans[i].RelPath < plans[j].RelPath })
+	for _, p := range plans {
+		fmt.Printf("%s %s\n", StyleBold.Render("Repo:"), StyleSuccess.Render(p.RelPath))
+		fmt.Println(StyleDim.Render("-----------------"))
+		switch {
+		case p.Error != "":
+			fmt.Println(StyleFailed.Render("would fail: " + p.Error))
+		case p.Skipped:
+			fmt.Println(StyleSkipped.Render("skip: " + p.Reason))
+		case len(p.Commands) == 0:
+			fmt.Println(StyleDim.Render("(nothing to do)"))
+		}
+		if p.Error == "" {
+			for _, c := range p.Commands {
+				fmt.Println(c)
+			}
+		}
+		fmt.Println(StyleDim.Render("================="))
+	}
+
+	fmt.Println("\n" + StyleBold.Render("--- Dry Run Summary ---"))
+	fmt.Printf("'%s': %s repos would change, %s skipped, %s would fail. No changes were made.\n",
+		opDesc,
+		StyleSuccess.Render(fmt.Sprintf("%d", changes)),
+		StyleSkipped.Render(fmt.Sprintf("%d", skipped)),
+		StyleFailed.Render(fmt.Sprintf("%d", failed)))
+
+	if failed > 0 {
+		return errReposFailed
+	}
+	return nil
+}
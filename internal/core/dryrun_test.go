@@ -0,0 +1,136 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSwitchRepoPlanDoesNotSwitch(t *testing.T) {
+	repoDir, _ := makeRepoWithRemote(t)
+	runCmd(t, repoDir, "git", "branch", "feature")
+
+	plan := &RepoPlan{}
+	res := switchRepo(context.Background(), RepoInfo{Path: repoDir, RelPath: "repo"}, "feature", "origin", nil, plan)
+	if !res.Success {
+		t.Fatalf("expected planned success, got %+v", res)
+	}
+	if len(plan.Commands) != 1 || plan.Commands[0] != "git switch feature" {
+		t.Errorf("unexpected plan: %v", plan.Commands)
+	}
+	if branch := strings.TrimSpace(string(runCmdOutput(t, repoDir, "git", "branch", "--show-current"))); branch != "main" {
+		t.Errorf("dry run switched branch to %s", branch)
+	}
+}
+
+func TestResetRepoPlanRecordsFetchAndReset(t *testing.T) {
+	repoDir, _ := makeRepoWithRemote(t)
+	head := string(runCmdOutput(t, repoDir, "git", "rev-parse", "HEAD"))
+
+	plan := &RepoPlan{}
+	res := resetRepo(context.Background(), RepoInfo{Path: repoDir, RelPath: "repo"}, "main", "hard", "origin", nil, plan)
+	if !res.Success {
+		t.Fatalf("expected planned success, got %+v", res)
+	}
+	want := []string{"git fetch origin main", "git reset --hard origin/main"}
+	if strings.Join(plan.Commands, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %v, got %v", want, plan.Commands)
+	}
+	if after := string(runCmdOutput(t, repoDir, "git", "rev-parse", "HEAD")); after != head {
+		t.Error("dry run moved HEAD")
+	}
+}
+
+func TestSoftResetPlanSeesUnfetchedRemoteCommits(t *testing.T) {
+	repoDir, remoteDir := makeRepoWithRemote(t)
+	repo := RepoInfo{Path: repoDir, RelPath: "repo"}
+	if res := resetRepo(context.Background(), repo, "main", "soft", "origin", nil, &RepoPlan{}); !res.Skipped {
+		t.Fatalf("expected an up-to-date repo to be skipped, got %+v", res)
+	}
+
+	other := filepath.Join(t.TempDir(), "other")
+	runCmd(t, repoDir, "git", "clone", remoteDir, other)
+	runCmd(t, other, "git", "-c", "user.name=test", "-c", "user.email=test@test.com", "commit", "--allow-empty", "-m", "upstream")
+	runCmd(t, other, "git", "push", "origin", "main")
+
+	plan := &RepoPlan{}
+	if res := resetRepo(context.Background(), repo, "main", "soft", "origin", nil, plan); res.Skipped {
+		t.Errorf("expected the unfetched remote commit to be planned, got %+v", res)
+	}
+	if len(plan.Commands) == 0 {
+		t.Error("expected planned fetch and reset commands")
+	}
+}
+
+func TestResetRepoPlanSkipsMissingRemote(t *testing.T) {
+	repoDir := t.TempDir()
+	createGitRepo(t, repoDir)
+
+	plan := &RepoPlan{}
+	res := resetRepo(context.Background(), RepoInfo{Path: repoDir, RelPath: "repo"}, "main", "soft", "origin", nil, plan)
+	if !res.Skipped || res.SkipReason != "no origin remote" {
+		t.Errorf("expected skip for missing remote, got %+v", res)
+	}
+	if len(plan.Commands) != 0 {
+		t.Errorf("expected no planned commands, got %v", plan.Commands)
+	}
+}
+
+func TestWorktreeCreateDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	createGitRepo(t, filepath.Join(tmpDir, "repo1"))
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.DryRun = true
+	cfg.Output = outputJSON
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	runErr := worktreeCreate(context.Background(), tmpDir, "feature", "main", 2, cfg)
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+	outBytes, _ := io.ReadAll(r)
+
+	if runErr != nil {
+		t.Fatalf("unexpected error: %v", runErr)
+	}
+	if _, err := os.Stat(worktreePath(filepath.Join(tmpDir, "repo1"), "feature")); err == nil {
+		t.Error("dry run created a worktree")
+	}
+
+	var doc struct {
+		Kind    string `json:"kind"`
+		Results []struct {
+			Result RepoPlan `json:"result"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(outBytes, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, outBytes)
+	}
+	if doc.Kind != "plan" || len(doc.Results) != 1 {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+	cmds := doc.Results[0].Result.Commands
+	if len(cmds) != 2 || cmds[0] != "git branch feature main" || !strings.HasPrefix(cmds[1], "git worktree add ") {
+		t.Errorf("unexpected plan: %v", cmds)
+	}
+}
+
+func TestWorktreeCreateDryRunMissingBase(t *testing.T) {
+	repoDir := t.TempDir()
+	createGitRepo(t, repoDir)
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+
+	plan := &RepoPlan{}
+	res := createWorktree(context.Background(), RepoInfo{Path: repoDir, RelPath: "repo"}, "feature", "nope", cfg, io.Discard, &ProgressState{quiet: true}, plan)
+	if res.Error == nil || !strings.Contains(res.Error.Error(), "base 'nope' not found") {
+		t.Errorf("expected missing base error, got %+v", res)
+	}
+}
@@ -85,7 +85,7 @@ func TestE2ERebaseFromDifferentBranchNotSkipped(t *testing.T) {
 		t.Errorf("expected current branch to remain 'feature', got %q", branch)
 	}
 
-	if !checkAlreadyAtTarget(repoDir, "main", "origin") {
+	if !checkAlreadyAtTarget(context.Background(), repoDir, "main", "origin") {
 		t.Error("expected HEAD to be at origin/main after rebase")
 	}
 }
@@ -111,7 +111,7 @@ func TestE2ESoftResetFromDifferentBranchNotSkipped(t *testing.T) {
 		t.Errorf("expected current branch to remain 'feature', got %q", branch)
 	}
 
-	if !checkAlreadyAtTarget(repoDir, "main", "origin") {
+	if !checkAlreadyAtTarget(context.Background(), repoDir, "main", "origin") {
 		t.Error("expected HEAD to be at origin/main after soft reset")
 	}
 }
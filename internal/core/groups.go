@@ -0,0 +1,98 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const groupPrefix = "@"
+
+func expandGroups(entries []string, groups map[string][]string) ([]string, error) {
+	var out []string
+	var expand func(entry string, visiting map[string]bool) error
+	expand = func(entry string, visiting map[string]bool) error {
+		name, isGroup := strings.CutPrefix(entry, groupPrefix)
+		if !isGroup {
+			out = append(out, entry)
+			return nil
+		}
+		members, ok := groups[name]
+		if !ok {
+			return fmt.Errorf("unknown group %q", entry)
+		}
+		if visiting[name] {
+			return fmt.Errorf("group %q includes itself", entry)
+		}
+		visiting[name] = true
+		defer delete(visiting, name)
+		for _, m := range members {
+			if err := expand(strings.TrimSpace(m), visiting); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, entry := range entries {
+		if err := expand(entry, make(map[string]bool)); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func listGroups(root string, cfg *Config) error {
+	if len(cfg.groups) == 0 {
+		fmt.Println("No groups defined. Add a 'groups' section to .gb.yaml to define some.")
+		return nil
+	}
+
+	names := make([]string, 0, len(cfg.groups))
+	for name := range cfg.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	allRepos, err := cfg.findRepos(root)
+	if err != nil {
+		return err
+	}
+	allRepos = cfg.filterWorktrees(allRepos)
+
+	for _, name := range names {
+		groupCfg, err := cfg.groupConfig(name)
+		if err != nil {
+			return err
+		}
+		var relPaths []string
+		if groupCfg != nil {
+			for _, r := range groupCfg.filterReposForExecution(allRepos) {
+				relPaths = append(relPaths, r.RelPath)
+			}
+		}
+		sort.Strings(relPaths)
+
+		fmt.Printf("%s %s %s\n", StyleBold.Render("Group:"), StyleSuccess.Render(groupPrefix+name),
+			StyleDim.Render(fmt.Sprintf("(%s)", strings.Join(cfg.groups[name], ", "))))
+		fmt.Println(StyleDim.Render("-----------------"))
+		if len(relPaths) == 0 {
+			fmt.Println(StyleDim.Render("(no matching repos)"))
+		}
+		for _, p := range relPaths {
+			fmt.Println(p)
+		}
+		fmt.Println(StyleDim.Render("================="))
+	}
+	return nil
+}
+
+// groupConfig returns a Config that includes only the members of the named
+// group, or nil if the group is empty.
+func (cfg *Config) groupConfig(name string) (*Config, error) {
+	expanded, err := expandGroups([]string{groupPrefix + name}, cfg.groups)
+	if err != nil || len(expanded) == 0 {
+		return nil, err
+	}
+	return newConfig(nil, expanded, nil, nil, cfg.PageSize, cfg.IncludeWorktrees, cfg.Remote, nil)
+}
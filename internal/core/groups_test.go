@@ -0,0 +1,111 @@
+package core
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandGroups(t *testing.T) {
+	groups := map[string][]string{
+		"backend":  {"services/*", "api"},
+		"frontend": {"web"},
+		"all":      {"@backend", "@frontend", "docs"},
+		"loop":     {"@loop2"},
+		"loop2":    {"@loop"},
+	}
+
+	got, err := expandGroups([]string{"@all", "extra"}, groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"services/*", "api", "web", "docs", "extra"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if _, err := expandGroups([]string{"@missing"}, groups); err == nil {
+		t.Error("expected error for unknown group")
+	}
+	if _, err := expandGroups([]string{"@loop"}, groups); err == nil {
+		t.Error("expected error for cyclic group")
+	}
+}
+
+func TestShouldExecuteInRepoWithGroups(t *testing.T) {
+	groups := map[string][]string{
+		"backend": {"services/*", "api"},
+		"legacy":  {"old-*"},
+	}
+
+	cfg, err := newConfig(nil, []string{"@backend"}, nil, nil, 20, false, "origin", groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for relPath, want := range map[string]bool{
+		filepath.Join("services", "auth"): true,
+		"api":                             true,
+		"web":                             false,
+	} {
+		if got := cfg.shouldExecuteInRepo(relPath); got != want {
+			t.Errorf("include @backend: %s = %v, want %v", relPath, got, want)
+		}
+	}
+
+	cfg, err = newConfig([]string{"@legacy"}, nil, nil, nil, 20, false, "origin", groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.shouldExecuteInRepo("old-billing") {
+		t.Error("expected old-billing excluded by @legacy")
+	}
+	if !cfg.shouldExecuteInRepo("web") {
+		t.Error("expected web to remain included")
+	}
+
+	if _, err := newConfig(nil, []string{"@nope"}, nil, nil, 20, false, "origin", groups); err == nil {
+		t.Error("expected error for unknown group in include list")
+	}
+}
+
+func TestListGroups(t *testing.T) {
+	tmpDir := t.TempDir()
+	createGitRepo(t, filepath.Join(tmpDir, "services", "auth"))
+	createGitRepo(t, filepath.Join(tmpDir, "web"))
+
+	groups := map[string][]string{
+		"backend": {"services/*"},
+		"empty":   {},
+	}
+	cfg, err := newConfig(defaultExcludeDirs, nil, nil, nil, 20, false, "origin", groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	listErr := listGroups(tmpDir, cfg)
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+	outBytes, _ := io.ReadAll(r)
+	output := string(outBytes)
+
+	if listErr != nil {
+		t.Fatal(listErr)
+	}
+	if !strings.Contains(output, "@backend") || !strings.Contains(output, filepath.Join("services", "auth")) {
+		t.Errorf("expected backend group with services/auth, got: %s", output)
+	}
+	if !strings.Contains(output, "(no matching repos)") {
+		t.Errorf("expected empty group placeholder, got: %s", output)
+	}
+	backendSection := output[strings.Index(output, "@backend"):strings.Index(output, "@empty")]
+	if strings.Contains(backendSection, "web") {
+		t.Errorf("expected web not listed under backend, got: %s", backendSection)
+	}
+}
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	repoCompleted   = "completed"
+	repoInterrupted = "interrupted"
+	repoNotStarted  = "not started"
+)
+
+// interrupter implements two-stage Ctrl-C. The first interrupt stops the
+// pools from dispatching new repos and lets in-flight ones finish; the
+// second cancels the run context, which kills every running command's
+// process group.
+type interrupter struct {
+	drain     context.Context
+	stopDrain context.CancelFunc
+	kill      context.CancelFunc
+	count     atomic.Int32
+
+	mu     sync.Mutex
+	states map[string]string
+	order  []string
+}
+
+type interrupterKey struct{}
+
+// HandleInterrupts returns a context for Run that carries the interrupt
+// handler. It is cancelled on the second Ctrl-C, not the first.
+func HandleInterrupts(parent context.Context) (context.Context, func()) {
+	ctx, kill := context.WithCancel(parent)
+	in := newInterrupter(ctx, kill)
+
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-sigCh:
+				fmt.Fprintln(os.Stderr, StyleSkipped.Render(interruptMessage(in.interrupt())))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return context.WithValue(ctx, interrupterKey{}, in), func() {
+		signal.Stop(sigCh)
+		close(done)
+		in.stopDrain()
+		kill()
+	}
+}
+
+func newInterrupter(ctx context.Context, kill context.CancelFunc) *interrupter {
+	drain, stopDrain := context.WithCancel(ctx)
+	return &interrupter{drain: drain, stopDrain: stopDrain, kill: kill, states: make(map[string]string)}
+}
+
+func interrupterFrom(ctx context.Context) *interrupter {
+	in, _ := ctx.Value(interrupterKey{}).(*interrupter)
+	return in
+}
+
+// interrupt advances to the next stage and returns it.
+func (in *interrupter) interrupt() int {
+	stage := int(in.count.Add(1))
+	if stage == 1 {
+		in.stopDrain()
+	} else {
+		in.kill()
+	}
+	return stage
+}
+
+func interruptMessage(stage int) string {
+	if stage == 1 {
+		return "Interrupted: no new repos will start; waiting for in-flight repos to finish (Ctrl-C again to kill them)"
+	}
+	return "Killing in-flight commands..."
+}
+
+func (in *interrupter) draining() bool {
+	return in != nil && in.drain.Err() != nil
+}
+
+func (in *interrupter) interrupted() bool {
+	return in != nil && in.count.Load() > 0
+}
+
+// record notes how a repo left the pool. A repo seen by several pools in one
+// run keeps the state from the last one.
+func (in *interrupter) record(relPath, state string) {
+	if in == nil {
+		return
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if _, ok := in.states[relPath]; !ok {
+		in.order = append(in.order, relPath)
+	}
+	in.states[relPath] = state
+}
+
+func (in *interrupter) report(w io.Writer) {
+	if !in.interrupted() {
+		return
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	byState := make(map[string][]string)
+	for _, relPath := range in.order {
+		state := in.states[relPath]
+		byState[state] = append(byState[state], relPath)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, StyleSkipped.Render(fmt.Sprintf("Run interrupted: %d completed, %d interrupted in flight, %d never started",
+		len(byState[repoCompleted]), len(byState[repoInterrupted]), len(byState[repoNotStarted]))))
+	sections := []struct {
+		state, title string
+	}{
+		{repoCompleted, "Completed"},
+		{repoInterrupted, "Interrupted in flight (check these repos)"},
+		{repoNotStarted, "Never started"},
+	}
+	for _, s := range sections {
+		if len(byState[s.state]) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "  %s:\n", s.title)
+		for _, relPath := range byState[s.state] {
+			fmt.Fprintf(w, "    %s\n", relPath)
+		}
+	}
+}
+
+// interruptedResult reports whether a result finished by failing after the
+// run context was killed, rather than on its own.
+func interruptedResult(ctx context.Context, res any) bool {
+	if ctx.Err() == nil {
+		return false
+	}
+	rr, ok := res.(repoResult)
+	if !ok {
+		return true
+	}
+	state, _ := rr.outcome()
+	return state == statusFailed
+}
+
+// newCmd and newCmdContext start commands in their own process group so a
+// terminal Ctrl-C reaches only gb, which decides what to stop. Commands
+// bound to a context have their whole group killed when it is cancelled.
+func newCmd(name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	isolateProcessGroup(cmd)
+	return cmd
+}
+
+func newCmdContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	isolateProcessGroup(cmd)
+	cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	return cmd
+}
+
+// shellWaitDelay bounds how long a killed -sh command's leftover children
+// can hold its output open.
+const shellWaitDelay = 2 * time.Second
+
+// newShellCmdContext runs a -sh command in gb's own process group so it keeps
+// the terminal and can prompt. The terminal's Ctrl-C reaches it directly,
+// and a cancelled ctx kills the shell.
+func newShellCmdContext(ctx context.Context, command string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/c", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.WaitDelay = shellWaitDelay
+	return cmd
+}
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func withTestInterrupter(t *testing.T) (context.Context, *interrupter) {
+	ctx, kill := context.WithCancel(context.Background())
+	t.Cleanup(kill)
+	in := newInterrupter(ctx, kill)
+	return context.WithValue(ctx, interrupterKey{}, in), in
+}
+
+func TestFirstInterruptLetsInFlightReposFinish(t *testing.T) {
+	ctx, in := withTestInterrupter(t)
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	opts := newRunOptions[CommandResult](cfg, newResultWriter("", "command"), &ProgressState{quiet: true})
+
+	var started sync.WaitGroup
+	started.Add(2)
+	release := make(chan struct{})
+	go func() {
+		started.Wait()
+		in.interrupt()
+		close(release)
+	}()
+
+	results := runPoolWith(ctx, testRepos(6), 2, opts, func(ctx context.Context, r RepoInfo) CommandResult {
+		started.Done()
+		<-release
+		if ctx.Err() != nil {
+			t.Errorf("%s: in-flight context cancelled by the first interrupt", r.RelPath)
+		}
+		return CommandResult{RelPath: r.RelPath}
+	})
+
+	completed, cancelled := 0, 0
+	for _, res := range results {
+		if res.Cancelled {
+			cancelled++
+		} else {
+			completed++
+		}
+	}
+	if completed != 2 || cancelled != 4 {
+		t.Fatalf("expected 2 completed and 4 cancelled, got %d/%d", completed, cancelled)
+	}
+
+	var buf bytes.Buffer
+	in.report(&buf)
+	if !strings.Contains(buf.String(), "2 completed, 0 interrupted in flight, 4 never started") {
+		t.Errorf("unexpected report:\n%s", buf.String())
+	}
+}
+
+func TestSecondInterruptMarksInFlightInterrupted(t *testing.T) {
+	ctx, in := withTestInterrupter(t)
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	opts := newRunOptions[SwitchResult](cfg, newResultWriter("", "switch"), &ProgressState{quiet: true})
+
+	results := runPoolWith(ctx, testRepos(3), 1, opts, func(ctx context.Context, r RepoInfo) SwitchResult {
+		in.interrupt()
+		in.interrupt()
+		<-ctx.Done()
+		return SwitchResult{RelPath: r.RelPath, Error: ctx.Err().Error()}
+	})
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+
+	var buf bytes.Buffer
+	in.report(&buf)
+	out := buf.String()
+	if !strings.Contains(out, "0 completed, 1 interrupted in flight, 2 never started") {
+		t.Errorf("unexpected report:\n%s", out)
+	}
+	if !strings.Contains(out, "Interrupted in flight") || !strings.Contains(out, "repo00") {
+		t.Errorf("expected repo00 listed as interrupted:\n%s", out)
+	}
+}
+
+func TestReportSilentWithoutInterrupt(t *testing.T) {
+	_, in := withTestInterrupter(t)
+	in.record("repo", repoCompleted)
+	var buf bytes.Buffer
+	in.report(&buf)
+	var nilIn *interrupter
+	nilIn.report(&buf)
+	if buf.Len() != 0 {
+		t.Errorf("expected no report, got %q", buf.String())
+	}
+}
+
+func TestCancelKillsWholeProcessGroup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	// The backgrounded sleep holds the output pipe open, so the command only
+	// returns promptly if the whole group is killed, not just sh.
+	cmd := newCmdContext(ctx, "sh", "-c", "sleep 30 & wait")
+
+	done := make(chan struct{})
+	go func() {
+		_, _ = cmd.CombinedOutput()
+		close(done)
+	}()
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("command still running after its context was cancelled")
+	}
+}
+
+func TestCancelledGitCommandIsNotRetried(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell alias")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	var log bytes.Buffer
+	start := time.Now()
+	retries, err := executeGitCommandWithRetryToFile(ctx, t.TempDir(), &log, "-c", "alias.wait=!sleep 30", "wait")
+	if err == nil || retries != 0 || strings.Contains(log.String(), "Retry") {
+		t.Errorf("expected a single failed attempt, got %d retries, err %v, log:\n%s", retries, err, log.String())
+	}
+	if elapsed := time.Since(start); elapsed > retryDelay {
+		t.Errorf("expected the cancel to return at once, took %s", elapsed)
+	}
+}
+
+func TestCancelledResetStopsHungLsRemote(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep as the ssh command")
+	}
+	repoDir, _ := makeRepoWithRemote(t)
+	runCmd(t, repoDir, "git", "remote", "set-url", "origin", "ssh://example.invalid/repo.git")
+	t.Setenv("GIT_SSH_COMMAND", "sleep 30")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	start := time.Now()
+	res := resetRepo(ctx, RepoInfo{Path: repoDir, RelPath: "repo"}, "main", "hard", "origin", nil, nil)
+	if res.Success {
+		t.Errorf("expected the reset to fail once cancelled, got %+v", res)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the cancel to stop ls-remote, took %s", elapsed)
+	}
+}
+
+func TestIsolatedCommandsLeaveGitPromptsEnabled(t *testing.T) {
+	t.Setenv("GIT_TERMINAL_PROMPT", "")
+	_ = os.Unsetenv("GIT_TERMINAL_PROMPT")
+
+	if err := newCmd("git", "--version").Run(); err != nil {
+		t.Fatal(err)
+	}
+	if v, set := os.LookupEnv("GIT_TERMINAL_PROMPT"); set {
+		t.Errorf("expected gb's environment to be left alone, got GIT_TERMINAL_PROMPT=%q", v)
+	}
+}
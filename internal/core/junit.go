@@ -0,0 +1,138 @@
+package core
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const junitLogTailLines = 50
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Skipped   *junitMessage `xml:"skipped"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// junitReport collects a run's results into a JUnit XML file, one testcase
+// per repo. It is nil without --junit, and its methods do nothing then.
+type junitReport struct {
+	path      string
+	operation string
+	results   []repoResult
+}
+
+func (cfg *Config) newJUnitReport(operation string) *junitReport {
+	if cfg.JUnit == "" {
+		return nil
+	}
+	return &junitReport{path: cfg.JUnit, operation: operation}
+}
+
+func (j *junitReport) add(res repoResult) {
+	if j == nil {
+		return
+	}
+	j.results = append(j.results, res)
+}
+
+// finish writes the report. Durations and log tails come from lm, which may
+// be nil; durations then come from the results.
+func (j *junitReport) finish(lm *LogManager) error {
+	if j == nil {
+		return nil
+	}
+	start := time.Now()
+	if lm != nil {
+		start = lm.start
+	}
+	suite := junitTestSuite{
+		Name:      j.operation,
+		Timestamp: start.Format(time.RFC3339),
+		Time:      junitSeconds(time.Since(start)),
+	}
+	sort.SliceStable(j.results, func(a, b int) bool { return j.results[a].repoPath() < j.results[b].repoPath() })
+	for _, res := range j.results {
+		state, msg := res.outcome()
+		var d time.Duration
+		if lm != nil {
+			d = lm.duration(res.repoPath())
+		} else if t, ok := res.(timedResult); ok {
+			d = t.duration()
+		}
+		tc := junitTestCase{ClassName: j.operation, Name: res.repoPath(), Time: junitSeconds(d)}
+		switch state {
+		case statusFailed:
+			suite.Failures++
+			body := msg
+			if lm != nil {
+				if content, err := lm.ReadLog(res.repoPath()); err == nil && strings.TrimSpace(content) != "" {
+					body += "\n\n" + logTail(content, junitLogTailLines)
+				}
+			}
+			tc.Failure = &junitMessage{Message: firstLine(msg), Body: body}
+		case statusSkipped, statusCancelled:
+			suite.Skipped++
+			if msg == "" {
+				msg = state
+			}
+			tc.Skipped = &junitMessage{Message: msg}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Tests = len(suite.Cases)
+
+	data, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), data...)
+	if err := os.WriteFile(j.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	return nil
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// logTail returns the last n lines of a log.
+func logTail(content string, n int) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
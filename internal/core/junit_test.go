@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJUnitReport(t *testing.T) {
+	lm, err := NewLogManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lm.Cleanup() }()
+	f, err := lm.CreateLogFile("services/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 60 {
+		_, _ = fmt.Fprintf(f, "line %d <&>\n", i)
+	}
+	_ = f.Close()
+	failed := CommandResult{RelPath: "services/web", Error: errors.New("exit status 1\nmore")}
+	lm.recordResult(failed)
+
+	path := filepath.Join(t.TempDir(), "report.xml")
+	cfg := &Config{JUnit: path}
+	junit := cfg.newJUnitReport("git fetch")
+	junit.add(failed)
+	junit.add(CommandResult{RelPath: "services/api"})
+	junit.add(ResetResult{RelPath: "tools/cli", Skipped: true, SkipReason: "dirty working tree"})
+	junit.add(SwitchResult{RelPath: "tools/gen", Cancelled: true})
+	if err := junit.finish(lm); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(data, &report); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, data)
+	}
+	suite := report.Suites[0]
+	if suite.Tests != 4 || suite.Failures != 1 || suite.Skipped != 2 {
+		t.Errorf("unexpected counts: %d tests, %d failures, %d skipped", suite.Tests, suite.Failures, suite.Skipped)
+	}
+	web := suite.Cases[1]
+	if web.ClassName != "git fetch" || web.Name != "services/web" || web.Failure == nil || web.Failure.Message != "exit status 1" {
+		t.Fatalf("unexpected failed testcase: %+v", web)
+	}
+	if !strings.HasPrefix(web.Failure.Body, "exit status 1\nmore\n\nline 10 <&>\n") || strings.Contains(web.Failure.Body, "line 9 ") {
+		t.Errorf("expected the error and the last 50 log lines, got:\n%s", web.Failure.Body)
+	}
+	if c := suite.Cases[2]; c.Skipped == nil || c.Skipped.Message != "dirty working tree" {
+		t.Errorf("expected the skip reason, got %+v", c)
+	}
+	if c := suite.Cases[3]; c.Skipped == nil || c.Skipped.Message != statusCancelled {
+		t.Errorf("expected cancelled repos to be skipped, got %+v", c)
+	}
+	if suite.Cases[0].Failure != nil || suite.Cases[0].Skipped != nil {
+		t.Errorf("expected a passing testcase, got %+v", suite.Cases[0])
+	}
+}
+
+func TestCloneWritesJUnitReport(t *testing.T) {
+	remote := makeBareRemote(t)
+	m := &Manifest{Version: manifestVersion, Repos: []manifestRepo{
+		{Path: "app", Remotes: []manifestRemote{{Name: "origin", URL: remote}}},
+		{Path: "broken", Remotes: []manifestRemote{{Name: "origin", URL: filepath.Join(t.TempDir(), "missing.git")}}},
+	}}
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+	cfg.JUnit = filepath.Join(t.TempDir(), "clone.xml")
+	_ = runQuiet(t, func() error { return cloneWorkspace(context.Background(), t.TempDir(), m, 2, cfg) })
+
+	data, err := os.ReadFile(cfg.JUnit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	suite := report.Suites[0]
+	if suite.Name != "clone" || suite.Tests != 2 || suite.Failures != 1 {
+		t.Fatalf("unexpected suite: %+v", suite)
+	}
+	if suite.Cases[0].Name != "app" || suite.Cases[0].Failure != nil || suite.Cases[1].Failure == nil {
+		t.Errorf("expected app to pass and broken to fail, got %+v", suite.Cases)
+	}
+}
@@ -1,39 +1,103 @@
 package core
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
+const (
+	runRecordFile = "run.json"
+	runLogsDir    = "logs"
+	runRetention  = 50
+)
+
+type runRepoRecord struct {
+	RelPath    string `json:"relPath"`
+	Status     string `json:"status"`
+	Message    string `json:"message,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	Log        string `json:"log,omitempty"`
+}
+
+// runRecord is the metadata saved next to a run's logs, in the same
+// runs/<run-id> directory as the undo journal.
+type runRecord struct {
+	ID      string          `json:"id"`
+	Command string          `json:"command"`
+	Args    []string        `json:"args"`
+	Root    string          `json:"root"`
+	Start   time.Time       `json:"start"`
+	End     *time.Time      `json:"end,omitempty"`
+	Repos   []runRepoRecord `json:"repos"`
+}
+
 type LogManager struct {
-	tempDir  string
+	id       string
+	start    time.Time
+	logDir   string
 	logFiles map[string]string
 	mu       sync.Mutex
+
+	// run is nil when the state dir was unusable and logs went to a temp
+	// dir instead.
+	run     *runRecord
+	runDir  string
+	results map[string]runRepoRecord
 }
 
 func NewLogManager() (*LogManager, error) {
+	return newLogManager(newRunID(), "", "", nil)
+}
+
+func (cfg *Config) newLogManager(root, command string) (*LogManager, error) {
+	return newLogManager(newRunID(), root, command, cfg.args)
+}
+
+func newLogManager(id, root, command string, args []string) (*LogManager, error) {
+	lm := &LogManager{
+		id:       id,
+		start:    time.Now(),
+		logFiles: make(map[string]string),
+		results:  make(map[string]runRepoRecord),
+	}
+
+	if dir, err := runsDir(); err == nil {
+		runDir := filepath.Join(dir, id)
+		if err := os.MkdirAll(filepath.Join(runDir, runLogsDir), 0o755); err == nil {
+			lm.runDir = runDir
+			lm.logDir = filepath.Join(runDir, runLogsDir)
+			lm.run = &runRecord{ID: id, Command: command, Args: args, Root: root, Start: lm.start}
+			pruneRuns(dir, id)
+			_ = lm.save()
+			return lm, nil
+		}
+	}
+
 	tempDir, err := os.MkdirTemp("", "gb-logs-*")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp directory: %w", err)
 	}
+	lm.logDir = tempDir
+	return lm, nil
+}
 
-	return &LogManager{
-		tempDir:  tempDir,
-		logFiles: make(map[string]string),
-	}, nil
+func logFileName(relPath string) string {
+	sanitized := strings.ReplaceAll(relPath, "/", "_")
+	sanitized = strings.ReplaceAll(sanitized, "\\", "_")
+	return sanitized + ".log"
 }
 
 func (lm *LogManager) CreateLogFile(relPath string) (*os.File, error) {
 	lm.mu.Lock()
 	defer lm.mu.Unlock()
 
-	sanitized := strings.ReplaceAll(relPath, "/", "_")
-	sanitized = strings.ReplaceAll(sanitized, "\\", "_")
-
-	logPath := filepath.Join(lm.tempDir, fmt.Sprintf("%s.log", sanitized))
+	logPath := filepath.Join(lm.logDir, logFileName(relPath))
 
 	f, err := os.Create(logPath)
 	if err != nil {
@@ -44,6 +108,77 @@ func (lm *LogManager) CreateLogFile(relPath string) (*os.File, error) {
 	return f, nil
 }
 
+// recordResult notes a repo's outcome and how long it took.
+func (lm *LogManager) recordResult(res repoResult) {
+	state, msg := res.outcome()
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	rec := runRepoRecord{RelPath: res.repoPath(), Status: state, Message: msg}
+	if t, ok := res.(timedResult); ok {
+		rec.DurationMs = t.duration().Milliseconds()
+	}
+	if logPath, ok := lm.logFiles[rec.RelPath]; ok {
+		rec.Log = filepath.Base(logPath)
+	}
+	lm.results[rec.RelPath] = rec
+}
+
+// duration reports how long a repo took, as recorded by recordResult.
+func (lm *LogManager) duration(relPath string) time.Duration {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return time.Duration(lm.results[relPath].DurationMs) * time.Millisecond
+}
+
+// expectedDurations estimates how long each repo will take from earlier
+// runs of the same command in the same workspace. It is nil without any.
+func (lm *LogManager) expectedDurations(repos []RepoInfo) map[string]time.Duration {
+	if lm.run == nil {
+		return nil
+	}
+	return estimateDurations(repos, loadRepoTimings(lm.run.Root, lm.run.Command))
+}
+
+// finish stamps the end time and saves the run's metadata, and the timings
+// of the repos that ran to completion for estimating the next run.
+func (lm *LogManager) finish() {
+	if lm.run == nil {
+		return
+	}
+	now := time.Now()
+	durations := make(map[string]time.Duration)
+	lm.mu.Lock()
+	lm.run.End = &now
+	for _, rec := range lm.results {
+		if rec.Status == statusCompleted || rec.Status == statusFailed {
+			durations[rec.RelPath] = time.Duration(rec.DurationMs) * time.Millisecond
+		}
+	}
+	lm.mu.Unlock()
+	_ = lm.save()
+	_ = saveRepoTimings(lm.run.Root, lm.run.Command, durations)
+}
+
+func (lm *LogManager) save() error {
+	lm.mu.Lock()
+	lm.run.Repos = make([]runRepoRecord, 0, len(lm.results))
+	for _, rec := range lm.results {
+		lm.run.Repos = append(lm.run.Repos, rec)
+	}
+	sort.Slice(lm.run.Repos, func(i, j int) bool { return lm.run.Repos[i].RelPath < lm.run.Repos[j].RelPath })
+	data, err := json.MarshalIndent(lm.run, "", "  ")
+	lm.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(lm.runDir, runRecordFile), data, 0o644)
+}
+
+func (lm *LogManager) RunID() string {
+	return lm.id
+}
+
 func (lm *LogManager) GetLogPath(relPath string) (string, bool) {
 	lm.mu.Lock()
 	defer lm.mu.Unlock()
@@ -77,9 +212,147 @@ func (lm *LogManager) ReadLog(relPath string) (string, error) {
 }
 
 func (lm *LogManager) Cleanup() error {
-	return os.RemoveAll(lm.tempDir)
+	if lm.runDir != "" {
+		return os.RemoveAll(lm.runDir)
+	}
+	return os.RemoveAll(lm.logDir)
+}
+
+func (lm *LogManager) GetLogDir() string {
+	return lm.logDir
+}
+
+func printLogLocation(lm *LogManager) {
+	fmt.Printf("\nLogs are available at: %s\n", lm.GetLogDir())
+	if lm.run != nil {
+		fmt.Printf("Review them later with: gb logs %s\n", lm.id)
+	} else {
+		fmt.Println("You can review them later if needed.")
+	}
+}
+
+// pruneRuns drops the logs of all but the newest runRetention runs. Undo
+// journals are kept, so an old run can still be undone.
+func pruneRuns(dir, keepID string) {
+	ids, err := listRunIDs(dir)
+	if err != nil || len(ids) <= runRetention {
+		return
+	}
+	for _, id := range ids[runRetention:] {
+		if id == keepID {
+			continue
+		}
+		runDir := filepath.Join(dir, id)
+		_ = os.RemoveAll(filepath.Join(runDir, runLogsDir))
+		_ = os.Remove(filepath.Join(runDir, runRecordFile))
+		_ = os.Remove(runDir) // only succeeds once nothing else is left
+	}
+}
+
+// listRunIDs returns the IDs of runs with saved metadata, most recently
+// updated first. Run IDs only have one-second resolution, so they alone
+// cannot order runs started in the same second.
+func listRunIDs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var ids []string
+	modTimes := make(map[string]time.Time)
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, e.Name(), runRecordFile)); err == nil {
+			ids = append(ids, e.Name())
+			modTimes[e.Name()] = info.ModTime()
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if !modTimes[ids[i]].Equal(modTimes[ids[j]]) {
+			return modTimes[ids[i]].After(modTimes[ids[j]])
+		}
+		return ids[i] > ids[j]
+	})
+	return ids, nil
+}
+
+func readRunRecord(id string) (*runRecord, error) {
+	dir, err := runsDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, id, runRecordFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no logs for run %q", id)
+		}
+		return nil, err
+	}
+	var rec runRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("run %q: %w", id, err)
+	}
+	return &rec, nil
+}
+
+// resolveRunID maps "" and "last" to the newest run with logs.
+func resolveRunID(id string) (string, error) {
+	if id != "" && id != "last" {
+		return id, checkRunID(id)
+	}
+	dir, err := runsDir()
+	if err != nil {
+		return "", err
+	}
+	ids, err := listRunIDs(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(ids) == 0 {
+		return "", fmt.Errorf("no recorded runs")
+	}
+	return ids[0], nil
 }
 
-func (lm *LogManager) GetTempDir() string {
-	return lm.tempDir
+// checkRunID rejects a user-supplied run ID that would point outside the
+// runs directory.
+func checkRunID(id string) error {
+	if id == "" || id == "." || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return fmt.Errorf("invalid run id %q", id)
+	}
+	return nil
+}
+
+// openRun loads a saved run as a LogManager so the live-run log displays
+// can be reused for it.
+func openRun(id string) (*LogManager, error) {
+	rec, err := readRunRecord(id)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := runsDir()
+	if err != nil {
+		return nil, err
+	}
+	runDir := filepath.Join(dir, id)
+	lm := &LogManager{
+		id:       id,
+		start:    rec.Start,
+		logDir:   filepath.Join(runDir, runLogsDir),
+		logFiles: make(map[string]string),
+		run:      rec,
+		runDir:   runDir,
+		results:  make(map[string]runRepoRecord),
+	}
+	for _, r := range rec.Repos {
+		lm.results[r.RelPath] = r
+		if r.Log != "" {
+			lm.logFiles[r.RelPath] = filepath.Join(lm.logDir, r.Log)
+		}
+	}
+	return lm, nil
 }
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const logsUsage = "usage: gb logs [run-id|last] [repo] | gb logs failed [run-id|last]"
+
+func showLogs(args []string) error {
+	switch {
+	case len(args) == 0:
+		return listRuns()
+	case args[0] == "failed" && len(args) <= 2:
+		id := ""
+		if len(args) == 2 {
+			id = args[1]
+		}
+		return showFailedLogs(id)
+	case len(args) == 1:
+		return showRun(args[0])
+	case len(args) == 2:
+		return showRepoLog(args[0], args[1])
+	}
+	return fmt.Errorf(logsUsage)
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+func runCounts(rec *runRecord) string {
+	counts := make(map[string]int)
+	for _, r := range rec.Repos {
+		counts[r.Status]++
+	}
+	parts := []string{StyleSuccess.Render(fmt.Sprintf("%d ok", counts[statusCompleted]))}
+	if n := counts[statusFailed]; n > 0 {
+		parts = append(parts, StyleFailed.Render(fmt.Sprintf("%d failed", n)))
+	}
+	if n := counts[statusSkipped]; n > 0 {
+		parts = append(parts, StyleSkipped.Render(fmt.Sprintf("%d skipped", n)))
+	}
+	if n := counts[statusCancelled]; n > 0 {
+		parts = append(parts, StyleDim.Render(fmt.Sprintf("%d cancelled", n)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func runElapsed(rec *runRecord) string {
+	if rec.End == nil {
+		return "unfinished"
+	}
+	return formatDuration(rec.End.Sub(rec.Start))
+}
+
+func listRuns() error {
+	dir, err := runsDir()
+	if err != nil {
+		return err
+	}
+	ids, err := listRunIDs(dir)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		fmt.Println("No recorded runs.")
+		return nil
+	}
+	if len(ids) > historyLimit {
+		ids = ids[:historyLimit]
+	}
+
+	for _, id := range ids {
+		rec, err := readRunRecord(id)
+		if err != nil {
+			continue
+		}
+		fmt.Printf("%s  %s  %s  %s  %s\n",
+			StyleSuccess.Render(rec.ID),
+			StyleDim.Render(rec.Start.Format("2006-01-02 15:04:05")),
+			rec.Command,
+			runCounts(rec),
+			StyleDim.Render(runElapsed(rec)))
+	}
+	fmt.Println()
+	fmt.Println(StyleDim.Render("Show a run with: gb logs <run-id>"))
+	return nil
+}
+
+func runStatusIcon(status string) string {
+	switch status {
+	case statusCompleted:
+		return "✅"
+	case statusFailed:
+		return "❌"
+	case statusSkipped:
+		return "⏭️"
+	case statusCancelled:
+		return "🚫"
+	}
+	return "⏳"
+}
+
+func showRun(id string) error {
+	id, err := resolveRunID(id)
+	if err != nil {
+		return err
+	}
+	rec, err := readRunRecord(id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s\n", StyleBold.Render("Run:"), StyleSuccess.Render(rec.ID))
+	fmt.Printf("%s  %s  %s\n", StyleDim.Render(rec.Start.Format("2006-01-02 15:04:05")), rec.Command, StyleDim.Render(rec.Root))
+	if len(rec.Args) > 0 {
+		fmt.Println(StyleDim.Render("gb " + quoteCommandLine(rec.Args)))
+	}
+	fmt.Printf("%s  %s\n", runCounts(rec), StyleDim.Render(runElapsed(rec)))
+	fmt.Println(StyleDim.Render("-----------------"))
+
+	width := 0
+	for _, r := range rec.Repos {
+		width = max(width, len(r.RelPath))
+	}
+	for _, r := range rec.Repos {
+		line := fmt.Sprintf("%s %-*s  %s", runStatusIcon(r.Status), width, r.RelPath, StyleDim.Render(formatDuration(time.Duration(r.DurationMs)*time.Millisecond)))
+		if r.Message != "" {
+			style := StyleDim
+			if r.Status == statusFailed {
+				style = StyleErrInline
+			}
+			line += "  " + style.Render(strings.ReplaceAll(r.Message, "\n", " "))
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+	fmt.Println(StyleDim.Render("Show a repo's log with: gb logs " + rec.ID + " <repo>"))
+	return nil
+}
+
+func showRepoLog(id, relPath string) error {
+	id, err := resolveRunID(id)
+	if err != nil {
+		return err
+	}
+	lm, err := openRun(id)
+	if err != nil {
+		return err
+	}
+	content, err := lm.ReadLog(relPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("log for %s in run %s has been removed", relPath, id)
+		}
+		return fmt.Errorf("no log for %s in run %s", relPath, id)
+	}
+	fmt.Print(content)
+	return nil
+}
+
+func showFailedLogs(id string) error {
+	id, err := resolveRunID(id)
+	if err != nil {
+		return err
+	}
+	lm, err := openRun(id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s  %s\n", StyleBold.Render("Run:"), StyleSuccess.Render(lm.run.ID), lm.run.Command)
+	failed := 0
+	for _, r := range lm.run.Repos {
+		if r.Status != statusFailed {
+			continue
+		}
+		failed++
+		displayRepoLog(lm, r.RelPath, StyleFailed.Render("FAILED"))
+	}
+	if failed == 0 {
+		fmt.Println(StyleDim.Render("No failed repos in this run."))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecuteShellInReposSavesRun(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	createGitRepo(t, filepath.Join(tmpDir, "ok"))
+	createGitRepo(t, filepath.Join(tmpDir, "bad"))
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+	cfg.args = []string{"-sh", "echo out-$GB_NAME; test $GB_NAME = ok"}
+
+	_ = runQuiet(t, func() error { return executeShellInRepos(context.Background(), tmpDir, cfg.args[1], 2, cfg) })
+
+	id, err := resolveRunID("last")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := readRunRecord(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Command != cfg.args[1] || strings.Join(rec.Args, " ") != strings.Join(cfg.args, " ") || rec.Root != tmpDir || rec.End == nil {
+		t.Errorf("unexpected run metadata: %+v", rec)
+	}
+	statuses := make(map[string]string)
+	for _, r := range rec.Repos {
+		statuses[r.RelPath] = r.Status
+	}
+	if statuses["ok"] != statusCompleted || statuses["bad"] != statusFailed {
+		t.Errorf("unexpected repo statuses: %v", statuses)
+	}
+
+	lm, err := openRun(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, err := lm.ReadLog("bad"); err != nil || !strings.Contains(content, "out-bad") {
+		t.Errorf("expected saved log for bad, got %q (%v)", content, err)
+	}
+}
+
+func TestPruneRunsKeepsJournals(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var ids []string
+	for i := range runRetention + 2 {
+		id := base.Add(time.Duration(i)*time.Minute).Format("20060102-150405") + "-aaaaaa"
+		ids = append(ids, id)
+		runDir := filepath.Join(dir, id)
+		if err := os.MkdirAll(filepath.Join(runDir, runLogsDir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(runRecord{ID: id})
+		writeFile(t, runDir, runRecordFile, string(data))
+	}
+	writeFile(t, filepath.Join(dir, ids[0]), journalFile, "{}")
+
+	pruneRuns(dir, ids[len(ids)-1])
+
+	remaining, err := listRunIDs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != runRetention || remaining[len(remaining)-1] != ids[2] {
+		t.Errorf("expected the newest %d runs to keep their logs, got %d", runRetention, len(remaining))
+	}
+	if _, err := os.Stat(filepath.Join(dir, ids[0], journalFile)); err != nil {
+		t.Errorf("expected the old run's journal to survive: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ids[1])); !os.IsNotExist(err) {
+		t.Errorf("expected the old run without a journal to be removed, got %v", err)
+	}
+}
+
+func TestShowLogsWithoutRuns(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	if err := showLogs([]string{"last"}); err == nil || !strings.Contains(err.Error(), "no recorded runs") {
+		t.Errorf("expected no recorded runs error, got %v", err)
+	}
+	if err := showLogs([]string{"a", "b", "c"}); err == nil || !strings.Contains(err.Error(), "usage") {
+		t.Errorf("expected usage error, got %v", err)
+	}
+}
+
+func TestRunIDCannotEscapeRunsDir(t *testing.T) {
+	for _, id := range []string{"../../etc", "a/b", `a\b`, "..", "."} {
+		if _, err := resolveRunID(id); err == nil {
+			t.Errorf("expected %q to be rejected", id)
+		}
+		if _, err := readJournal(id); err == nil || !strings.Contains(err.Error(), "invalid run id") {
+			t.Errorf("expected undo of %q to be rejected, got %v", id, err)
+		}
+	}
+	if id, err := resolveRunID("20260101-120000-abcdef"); err != nil || id != "20260101-120000-abcdef" {
+		t.Errorf("expected a plain run id to pass, got %q, %v", id, err)
+	}
+}
@@ -0,0 +1,329 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+const (
+	logGroupFailed    = "Failed"
+	logGroupSkipped   = "Skipped"
+	logGroupSucceeded = "Succeeded"
+)
+
+var (
+	logViewerSelected = lipgloss.NewStyle().Reverse(true)
+	logViewerMatch    = lipgloss.NewStyle().Background(lipgloss.Color("11")).Foreground(lipgloss.Color("0"))
+	logViewerPane     = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).BorderForeground(lipgloss.Color("8"))
+)
+
+type logViewerItem struct {
+	entry   logEntry
+	group   string
+	content string
+	path    string
+}
+
+type logMatch struct{ item, line int }
+
+// logViewer browses a run's logs: repos grouped by outcome on the left, the
+// selected repo's log on the right, with search across every log.
+type logViewer struct {
+	items     []logViewerItem
+	cursor    int
+	viewport  viewport.Model
+	search    textinput.Model
+	searching bool
+	query     string
+	matches   []logMatch
+	match     int
+	status    string
+	width     int
+	height    int
+}
+
+func newLogViewer(logManager *LogManager, entries []logEntry) logViewer {
+	var failed, skipped, succeeded []logViewerItem
+	for _, e := range entries {
+		if e.cancelled {
+			continue
+		}
+		item := logViewerItem{entry: e}
+		item.path, _ = logManager.GetLogPath(e.relPath)
+		if content, err := logManager.ReadLog(e.relPath); err == nil {
+			item.content = strings.TrimRight(content, "\n")
+		} else {
+			item.content = "Error reading log: " + err.Error()
+		}
+		switch {
+		case e.failed:
+			item.group = logGroupFailed
+			failed = append(failed, item)
+		case e.skipped:
+			item.group = logGroupSkipped
+			if e.skipReason != "" {
+				item.content = "Skipped: " + e.skipReason + "\n\n" + item.content
+			}
+			skipped = append(skipped, item)
+		default:
+			item.group = logGroupSucceeded
+			succeeded = append(succeeded, item)
+		}
+	}
+
+	search := textinput.New()
+	search.Prompt = "/"
+
+	v := logViewer{
+		items:    append(append(failed, skipped...), succeeded...),
+		viewport: viewport.New(80, 20),
+		search:   search,
+		width:    100,
+		height:   24,
+	}
+	v.resize()
+	v.showSelected()
+	return v
+}
+
+func logGroupIcon(group string) string {
+	switch group {
+	case logGroupFailed:
+		return "❌"
+	case logGroupSkipped:
+		return "⏭️"
+	}
+	return "✅"
+}
+
+func (v *logViewer) listWidth() int {
+	w := lipgloss.Width(logGroupSucceeded + " (999)")
+	for _, item := range v.items {
+		w = max(w, lipgloss.Width(item.entry.relPath)+4)
+	}
+	return min(w, max(v.width*2/5, 20))
+}
+
+func (v *logViewer) paneHeight() int {
+	return max(v.height-4, 3)
+}
+
+func (v *logViewer) resize() {
+	v.viewport.Width = max(v.width-v.listWidth()-4, 10)
+	v.viewport.Height = v.paneHeight() - 1
+}
+
+func (v *logViewer) showSelected() {
+	if len(v.items) == 0 {
+		v.viewport.SetContent("")
+		return
+	}
+	content := v.items[v.cursor].content
+	if strings.TrimSpace(content) == "" {
+		content = "(no output)"
+	}
+	if v.query != "" {
+		content = highlightMatches(content, v.query)
+	}
+	v.viewport.SetContent(content)
+}
+
+func highlightMatches(content, query string) string {
+	re, err := regexp.Compile("(?i)" + regexp.QuoteMeta(query))
+	if err != nil {
+		return content
+	}
+	return re.ReplaceAllStringFunc(content, func(s string) string { return logViewerMatch.Render(s) })
+}
+
+func (v *logViewer) selectItem(i int) {
+	if i < 0 || i >= len(v.items) || i == v.cursor {
+		return
+	}
+	v.cursor = i
+	v.showSelected()
+	v.viewport.GotoTop()
+}
+
+// runSearch finds every line containing the query, case-insensitively, in
+// every log, in list order.
+func (v *logViewer) runSearch(query string) {
+	v.query = query
+	v.matches = nil
+	v.match = 0
+	if query == "" {
+		v.status = ""
+		v.showSelected()
+		return
+	}
+	q := strings.ToLower(query)
+	for i, item := range v.items {
+		for n, line := range strings.Split(item.content, "\n") {
+			if strings.Contains(strings.ToLower(line), q) {
+				v.matches = append(v.matches, logMatch{item: i, line: n})
+			}
+		}
+	}
+	if len(v.matches) == 0 {
+		v.status = fmt.Sprintf("No matches for %q", query)
+		v.showSelected()
+		return
+	}
+	v.jumpToMatch(0)
+}
+
+func (v *logViewer) jumpToMatch(i int) {
+	if len(v.matches) == 0 {
+		return
+	}
+	v.match = (i%len(v.matches) + len(v.matches)) % len(v.matches)
+	m := v.matches[v.match]
+	if m.item != v.cursor {
+		v.cursor = m.item
+	}
+	v.showSelected()
+	v.viewport.SetYOffset(max(m.line-v.viewport.Height/2, 0))
+	v.status = fmt.Sprintf("Match %d/%d for %q", v.match+1, len(v.matches), v.query)
+}
+
+func (v logViewer) Init() tea.Cmd {
+	return nil
+}
+
+func (v logViewer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.width, v.height = msg.Width, msg.Height
+		v.resize()
+		v.showSelected()
+		return v, nil
+
+	case tea.KeyMsg:
+		if v.searching {
+			switch msg.Type {
+			case tea.KeyEnter:
+				v.searching = false
+				v.search.Blur()
+				v.runSearch(v.search.Value())
+				return v, nil
+			case tea.KeyEsc, tea.KeyCtrlC:
+				v.searching = false
+				v.search.Blur()
+				return v, nil
+			}
+			var cmd tea.Cmd
+			v.search, cmd = v.search.Update(msg)
+			return v, cmd
+		}
+
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return v, tea.Quit
+		case "up", "k":
+			v.selectItem(v.cursor - 1)
+		case "down", "j":
+			v.selectItem(v.cursor + 1)
+		case "pgdown", " ", "ctrl+d":
+			v.viewport.HalfPageDown()
+		case "pgup", "ctrl+u":
+			v.viewport.HalfPageUp()
+		case "home", "g":
+			v.viewport.GotoTop()
+		case "end", "G":
+			v.viewport.GotoBottom()
+		case "/":
+			v.searching = true
+			v.search.SetValue("")
+			return v, v.search.Focus()
+		case "n":
+			v.jumpToMatch(v.match + 1)
+		case "N":
+			v.jumpToMatch(v.match - 1)
+		case "c":
+			if len(v.items) > 0 && v.items[v.cursor].path != "" {
+				termenv.Copy(v.items[v.cursor].path)
+				v.status = "Copied " + v.items[v.cursor].path
+			}
+		}
+		return v, nil
+	}
+	return v, nil
+}
+
+func (v logViewer) renderList() string {
+	height := v.paneHeight()
+	var lines []string
+	selectedLine := 0
+	group := ""
+	for i, item := range v.items {
+		if item.group != group {
+			group = item.group
+			count := 0
+			for _, other := range v.items {
+				if other.group == group {
+					count++
+				}
+			}
+			lines = append(lines, StyleBold.Render(fmt.Sprintf("%s (%d)", group, count)))
+		}
+		line := logGroupIcon(item.group) + " " + item.entry.relPath
+		if i == v.cursor {
+			selectedLine = len(lines)
+			line = logViewerSelected.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	// Keep the selection in view when the list is taller than the pane.
+	start := 0
+	if selectedLine >= height {
+		start = selectedLine - height + 1
+	}
+	end := min(start+height, len(lines))
+	return lipgloss.NewStyle().Width(v.listWidth()).Height(height).MaxWidth(v.listWidth()).
+		Render(strings.Join(lines[start:end], "\n"))
+}
+
+func (v logViewer) View() string {
+	if len(v.items) == 0 {
+		return "No logs to show.\n"
+	}
+
+	item := v.items[v.cursor]
+	title := StyleBold.Render(item.entry.relPath)
+	switch {
+	case item.group == logGroupFailed:
+		title += "  " + StyleFailed.Render("FAILED")
+	case item.group == logGroupSkipped:
+		title += "  " + StyleSkipped.Render("SKIPPED")
+	case item.entry.label != "":
+		title += "  " + item.entry.label
+	default:
+		title += "  " + StyleSuccess.Render("SUCCESS")
+	}
+	right := title + "\n" + v.viewport.View()
+
+	body := lipgloss.JoinHorizontal(lipgloss.Top,
+		logViewerPane.Render(v.renderList()),
+		logViewerPane.Render(lipgloss.NewStyle().Width(v.viewport.Width).MaxWidth(v.viewport.Width).Render(right)))
+
+	footer := StyleDim.Render("↑↓ select  PgUp/PgDn scroll  / search  n/N next/prev match  c copy log path  q quit")
+	if v.searching {
+		footer = v.search.View()
+	} else if v.status != "" {
+		footer = v.status + "  " + footer
+	}
+	return body + "\n" + footer
+}
+
+func runLogViewer(logManager *LogManager, entries []logEntry) error {
+	_, err := tea.NewProgram(newLogViewer(logManager, entries), tea.WithAltScreen()).Run()
+	return err
+}
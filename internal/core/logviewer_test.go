@@ -0,0 +1,101 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newTestLogViewer(t *testing.T, logs map[string]string, entries []logEntry) logViewer {
+	t.Helper()
+	lm, err := NewLogManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = lm.Cleanup() })
+	for repo, content := range logs {
+		f, err := lm.CreateLogFile(repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = f.WriteString(content)
+		_ = f.Close()
+	}
+	return newLogViewer(lm, entries)
+}
+
+func keyPress(v logViewer, keys ...string) logViewer {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		m, _ := v.Update(msg)
+		v = m.(logViewer)
+	}
+	return v
+}
+
+func TestLogViewerGroupsByOutcome(t *testing.T) {
+	v := newTestLogViewer(t,
+		map[string]string{"ok": "all good\n", "bad": "fatal: boom\n", "skip": ""},
+		[]logEntry{
+			{relPath: "ok"},
+			{relPath: "skip", skipped: true, skipReason: "dirty"},
+			{relPath: "bad", failed: true},
+			{relPath: "never", cancelled: true},
+		})
+
+	var order []string
+	for _, item := range v.items {
+		order = append(order, item.entry.relPath)
+	}
+	if strings.Join(order, ",") != "bad,skip,ok" {
+		t.Fatalf("expected failed, skipped, then succeeded repos, got %v", order)
+	}
+
+	view := v.View()
+	for _, want := range []string{"Failed (1)", "Skipped (1)", "Succeeded (1)", "fatal: boom"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected view to contain %q:\n%s", want, view)
+		}
+	}
+
+	v = keyPress(v, "j")
+	if !strings.Contains(v.View(), "Skipped: dirty") {
+		t.Errorf("expected the skip reason in the log pane:\n%s", v.View())
+	}
+}
+
+func TestLogViewerSearchAcrossLogs(t *testing.T) {
+	v := newTestLogViewer(t,
+		map[string]string{"a": "line one\nneedle here\n", "b": "nothing\n", "c": "x\ny\nNEEDLE again\n"},
+		[]logEntry{{relPath: "a"}, {relPath: "b"}, {relPath: "c"}})
+
+	v = keyPress(v, "/", "n", "e", "e", "d", "l", "e", "enter")
+	if len(v.matches) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(v.matches))
+	}
+	if v.cursor != 0 || !strings.Contains(v.status, "Match 1/2") {
+		t.Errorf("expected the first match in a, got cursor %d, status %q", v.cursor, v.status)
+	}
+
+	v = keyPress(v, "n")
+	if v.items[v.cursor].entry.relPath != "c" || !strings.Contains(v.status, "Match 2/2") {
+		t.Errorf("expected n to jump to c, got %s (%q)", v.items[v.cursor].entry.relPath, v.status)
+	}
+
+	v = keyPress(v, "N", "N")
+	if v.items[v.cursor].entry.relPath != "c" {
+		t.Errorf("expected N to wrap around to c, got %s", v.items[v.cursor].entry.relPath)
+	}
+
+	v = keyPress(v, "/", "z", "z", "z", "enter")
+	if len(v.matches) != 0 || !strings.Contains(v.status, "No matches") {
+		t.Errorf("expected no matches, got %d (%q)", len(v.matches), v.status)
+	}
+}
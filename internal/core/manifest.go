@@ -0,0 +1,433 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const manifestVersion = 1
+
+type Manifest struct {
+	Version int            `yaml:"version"`
+	Repos   []manifestRepo `yaml:"repos"`
+	root    string
+}
+
+type manifestRepo struct {
+	Path    string           `yaml:"path"`
+	Remotes []manifestRemote `yaml:"remotes"`
+	Branch  string           `yaml:"branch,omitempty"`
+	Groups  []string         `yaml:"groups,omitempty"`
+}
+
+type manifestRemote struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+type CloneResult struct {
+	RelPath  string   `json:"relPath"`
+	Action   string   `json:"action"`
+	Success  bool     `json:"success"`
+	Warnings []string `json:"warnings"`
+	Error    string   `json:"error"`
+	output   string
+	repoTiming
+}
+
+func readManifest(file string) (*Manifest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if isXMLManifest(file, data) {
+		return parseXMLManifest(file)
+	}
+	return loadManifest(file)
+}
+
+func loadManifest(file string) (*Manifest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("manifest %s: %w", file, err)
+	}
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("manifest %s: unsupported version %d", file, m.Version)
+	}
+	seen := make(map[string]bool, len(m.Repos))
+	for i, r := range m.Repos {
+		clean := path.Clean(filepath.ToSlash(r.Path))
+		if r.Path == "" || escapesWorkspace(clean) {
+			return nil, fmt.Errorf("manifest %s: invalid repo path %q", file, r.Path)
+		}
+		if seen[clean] {
+			return nil, fmt.Errorf("manifest %s: duplicate repo path %q", file, r.Path)
+		}
+		if len(r.Remotes) == 0 || r.Remotes[0].URL == "" {
+			return nil, fmt.Errorf("manifest %s: repo %q has no remote URL", file, r.Path)
+		}
+		for j, rm := range r.Remotes {
+			if rm.Name == "" && j == 0 {
+				m.Repos[i].Remotes[j].Name = "origin"
+			} else if rm.Name == "" || rm.URL == "" {
+				return nil, fmt.Errorf("manifest %s: repo %q has a remote without name or url", file, r.Path)
+			}
+		}
+		seen[clean] = true
+		m.Repos[i].Path = clean
+	}
+	return m, nil
+}
+
+// escapesWorkspace reports whether a cleaned, slash-separated repo path
+// points outside the workspace.
+func escapesWorkspace(clean string) bool {
+	return path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../")
+}
+
+// isPinnedRevision reports whether a manifest revision names a commit or a
+// tag rather than a branch: a full SHA, or a ref outside refs/heads/.
+func isPinnedRevision(rev string) bool {
+	if strings.HasPrefix(rev, "refs/") {
+		return true
+	}
+	if len(rev) != 40 && len(rev) != 64 {
+		return false
+	}
+	for _, c := range rev {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeGroups returns groups with each manifest group added as a list of
+// repo paths, so they can be selected with -i @group like config groups.
+func (m *Manifest) mergeGroups(groups map[string][]string) map[string][]string {
+	merged := make(map[string][]string, len(groups))
+	for name, members := range groups {
+		merged[name] = members
+	}
+	for _, r := range m.Repos {
+		for _, g := range r.Groups {
+			merged[g] = append(merged[g], r.Path)
+		}
+	}
+	return merged
+}
+
+func (m *Manifest) repoInfos(root string) []RepoInfo {
+	repos := make([]RepoInfo, 0, len(m.Repos))
+	for _, r := range m.Repos {
+		rel := filepath.FromSlash(r.Path)
+		repos = append(repos, RepoInfo{Path: filepath.Join(root, rel), RelPath: rel})
+	}
+	return repos
+}
+
+func (m *Manifest) workspaceRoot(root string) string {
+	if m.root != "" {
+		return m.root
+	}
+	return root
+}
+
+func (m *Manifest) find(relPath string) (manifestRepo, bool) {
+	slashPath := filepath.ToSlash(filepath.Clean(relPath))
+	for _, r := range m.Repos {
+		if r.Path == slashPath {
+			return r, true
+		}
+	}
+	return manifestRepo{}, false
+}
+
+// checkedOutRepos returns the manifest repos present on disk, in place of
+// walking the filesystem.
+func (m *Manifest) checkedOutRepos(root string, w io.Writer) []RepoInfo {
+	var repos []RepoInfo
+	missing := 0
+	for _, r := range m.repoInfos(m.workspaceRoot(root)) {
+		if _, err := os.Stat(filepath.Join(r.Path, ".git")); err != nil {
+			missing++
+			continue
+		}
+		repos = append(repos, r)
+	}
+	if missing > 0 {
+		_, _ = fmt.Fprintf(w, "%d of %d manifest repos are not checked out (use gb clone to fetch them)\n", missing, len(m.Repos))
+	}
+	return repos
+}
+
+func cloneWorkspace(ctx context.Context, root string, m *Manifest, workers int, cfg *Config) error {
+	out := newResultWriter(cfg.Output, "clone")
+	root = m.workspaceRoot(root)
+	repos := cfg.filterReposForExecution(m.repoInfos(root))
+	if len(repos) == 0 {
+		_, _ = fmt.Fprintln(cfg.infoWriter(), "No repos match the specified include/exclude criteria")
+		return out.finish(false)
+	}
+	byPath := make(map[string]manifestRepo, len(m.Repos))
+	for _, r := range m.Repos {
+		byPath[filepath.FromSlash(r.Path)] = r
+	}
+
+	fmt.Fprintln(cfg.infoWriter(), StyleInfo.Render(fmt.Sprintf("Bootstrapping %d repos into %s with %d workers...", len(repos), root, min(workers, len(repos)))))
+
+	progress := cfg.newProgress(repos, "Cloning repos", workers, nil)
+	stop := progress.start()
+
+	results := runPoolWith(ctx, repos, workers, newRunOptions[CloneResult](cfg, out, progress), func(ctx context.Context, r RepoInfo) CloneResult {
+		progress.UpdateStatus(r.RelPath, statusProcessing, "")
+		res := cloneOrVerify(ctx, r, byPath[r.RelPath])
+		switch {
+		case !res.Success:
+			progress.UpdateStatus(r.RelPath, statusFailed, res.Error)
+		case res.Action == "verified":
+			progress.UpdateStatus(r.RelPath, statusSkipped, "already present")
+		default:
+			progress.UpdateStatus(r.RelPath, statusCompleted, "")
+		}
+		return res
+	})
+
+	var cloned, verified, failed, cancelled int
+	for _, res := range results {
+		switch {
+		case res.Action == "cancelled":
+			cancelled++
+		case !res.Success:
+			failed++
+		case res.Action == "verified":
+			verified++
+		default:
+			cloned++
+		}
+	}
+
+	stop()
+	reportCI(cfg, "clone", nil, results)
+
+	if out.enabled() {
+		reportTimings(cfg, results)
+		return out.finishRun(failed, cancelled)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].RelPath < results[j].RelPath })
+	for _, res := range results {
+		if !res.Success && res.Action != "cancelled" {
+			fmt.Printf("%s %s\n", StyleFailed.Render(res.RelPath+":"), res.Error)
+			if res.output != "" {
+				fmt.Println(StyleDim.Render(strings.TrimSpace(res.output)))
+			}
+		}
+		for _, w := range res.Warnings {
+			fmt.Printf("%s %s\n", StyleSkipped.Render(res.RelPath+":"), w)
+		}
+	}
+
+	fmt.Println("\n" + StyleBold.Render("--- Summary ---"))
+	fmt.Printf("Cloned %s repos, %s already present, %s failed%s\n",
+		StyleSuccess.Render(fmt.Sprintf("%d", cloned)),
+		StyleSkipped.Render(fmt.Sprintf("%d", verified)),
+		StyleFailed.Render(fmt.Sprintf("%d", failed)),
+		cancelledNote(cancelled))
+	reportTimings(cfg, results)
+
+	return runError(failed, cancelled)
+}
+
+func cloneOrVerify(ctx context.Context, r RepoInfo, mr manifestRepo) CloneResult {
+	res := CloneResult{RelPath: r.RelPath, Warnings: []string{}}
+
+	if entries, err := os.ReadDir(r.Path); err == nil && len(entries) > 0 {
+		if top, err := gitOutput(r.Path, "rev-parse", "--show-toplevel"); err != nil || !samePath(top, r.Path) {
+			res.Error = "path exists but is not a git repository"
+			return res
+		}
+		res.Action = "verified"
+		res.Warnings = append(res.Warnings, syncRemotes(r.Path, mr.Remotes)...)
+		if isPinnedRevision(mr.Branch) {
+			head, _ := gitOutput(r.Path, "rev-parse", "HEAD")
+			if want, err := gitOutput(r.Path, "rev-parse", "--verify", "-q", mr.Branch+"^{commit}"); err != nil || head != want {
+				res.Warnings = append(res.Warnings, fmt.Sprintf("at %.7s, manifest pins %s", head, mr.Branch))
+			}
+		} else if mr.Branch != "" {
+			if current, _ := gitOutput(r.Path, "branch", "--show-current"); current != mr.Branch {
+				res.Warnings = append(res.Warnings, fmt.Sprintf("on branch %q, manifest wants %q", current, mr.Branch))
+			}
+		}
+		res.Success = true
+		return res
+	}
+
+	res.Action = "cloned"
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0o755); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	primary := mr.Remotes[0]
+	gitOut, err := newCmdContext(ctx, "git", "clone", "--origin", primary.Name, primary.URL, r.Path).CombinedOutput()
+	res.output = string(gitOut)
+	if err != nil {
+		res.Error = "clone failed"
+		return res
+	}
+
+	res.Warnings = append(res.Warnings, syncRemotes(r.Path, mr.Remotes[1:])...)
+
+	if isPinnedRevision(mr.Branch) {
+		if err := checkoutPinned(ctx, r.Path, mr.Branch, primary.Name, nil, nil); err != nil {
+			res.Error = err.Error()
+			return res
+		}
+	} else if mr.Branch != "" {
+		if current, _ := gitOutput(r.Path, "branch", "--show-current"); current != mr.Branch {
+			switchOut, switchErr := newCmdContext(ctx, "git", "-C", r.Path, "switch", mr.Branch).CombinedOutput()
+			if switchErr != nil {
+				res.output += string(switchOut)
+				res.Error = fmt.Sprintf("branch %q not found", mr.Branch)
+				return res
+			}
+		}
+	}
+	res.Success = true
+	return res
+}
+
+// syncRemotes adds any missing remotes and reports ones whose URL differs.
+func syncRemotes(dir string, remotes []manifestRemote) []string {
+	var warnings []string
+	for _, rm := range remotes {
+		url, err := gitOutput(dir, "remote", "get-url", rm.Name)
+		switch {
+		case err != nil:
+			if addErr := newCmd("git", "-C", dir, "remote", "add", rm.Name, rm.URL).Run(); addErr != nil {
+				warnings = append(warnings, fmt.Sprintf("could not add remote %s", rm.Name))
+			}
+		case url != rm.URL:
+			warnings = append(warnings, fmt.Sprintf("remote %s is %s, manifest has %s", rm.Name, url, rm.URL))
+		}
+	}
+	return warnings
+}
+
+func samePath(a, b string) bool {
+	ea, errA := filepath.EvalSymlinks(a)
+	eb, errB := filepath.EvalSymlinks(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return ea == eb
+}
+
+func runManifest(ctx context.Context, root string, args []string, workers int, cfg *Config) error {
+	if len(args) == 0 || args[0] != "export" || len(args) > 2 {
+		return fmt.Errorf("usage: gb manifest export [file]")
+	}
+	w := io.Writer(os.Stdout)
+	if len(args) == 2 {
+		f, err := os.Create(args[1])
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	} else {
+		cfg.infoToStderr = true
+	}
+	return exportManifest(ctx, root, w, workers, cfg)
+}
+
+func exportManifest(ctx context.Context, root string, w io.Writer, workers int, cfg *Config) error {
+	repos, _ := discoverRepos(root, workers, cfg, false)
+	if repos == nil {
+		return fmt.Errorf("no repos to export")
+	}
+
+	groupCfgs := make(map[string]*Config, len(cfg.groups))
+	for name := range cfg.groups {
+		groupCfg, err := cfg.groupConfig(name)
+		if err != nil {
+			return err
+		}
+		if groupCfg != nil {
+			groupCfgs[name] = groupCfg
+		}
+	}
+
+	entries := runPool(ctx, repos, workers, func(_ context.Context, r RepoInfo) manifestRepo {
+		mr := manifestRepo{Path: filepath.ToSlash(r.RelPath)}
+		mr.Branch, _ = gitOutput(r.Path, "branch", "--show-current")
+		names, _ := gitOutput(r.Path, "remote")
+		remoteNames := strings.Fields(names)
+		sort.SliceStable(remoteNames, func(i, j int) bool {
+			return remoteNames[i] == cfg.Remote && remoteNames[j] != cfg.Remote
+		})
+		for _, name := range remoteNames {
+			if url, err := gitOutput(r.Path, "remote", "get-url", name); err == nil {
+				mr.Remotes = append(mr.Remotes, manifestRemote{Name: name, URL: url})
+			}
+		}
+		for name, groupCfg := range groupCfgs {
+			if groupCfg.shouldExecuteInRepo(r.RelPath) {
+				mr.Groups = append(mr.Groups, name)
+			}
+		}
+		sort.Strings(mr.Groups)
+		return mr
+	})
+
+	m := Manifest{Version: manifestVersion}
+	for _, mr := range entries {
+		if len(mr.Remotes) == 0 {
+			fmt.Fprintf(cfg.infoWriter(), "%s no remotes, not exported\n", StyleSkipped.Render(mr.Path+":"))
+			continue
+		}
+		m.Repos = append(m.Repos, mr)
+	}
+	sort.Slice(m.Repos, func(i, j int) bool { return m.Repos[i].Path < m.Repos[j].Path })
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintln(cfg.infoWriter(), StyleSuccess.Render(fmt.Sprintf("Exported %d repos", len(m.Repos))))
+	return nil
+}
+
+func (r CloneResult) repoPath() string { return r.RelPath }
+
+func (r CloneResult) outcome() (string, string) {
+	switch {
+	case r.Action == "cancelled":
+		return statusCancelled, ""
+	case !r.Success:
+		return statusFailed, r.Error
+	case r.Action == "verified":
+		return statusSkipped, "already present"
+	}
+	return statusCompleted, ""
+}
+
+func (CloneResult) cancelledResult(r RepoInfo) CloneResult {
+	return CloneResult{RelPath: r.RelPath, Action: "cancelled"}
+}
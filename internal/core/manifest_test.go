@@ -0,0 +1,155 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func makeBareRemote(t *testing.T, branches ...string) string {
+	t.Helper()
+	seed := t.TempDir()
+	createGitRepo(t, seed)
+	for _, b := range branches {
+		runCmd(t, seed, "git", "branch", b)
+	}
+	bare := filepath.Join(t.TempDir(), "remote.git")
+	runCmd(t, seed, "git", "clone", "--bare", seed, bare)
+	return bare
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	apiRemote := makeBareRemote(t, "develop")
+	webRemote := makeBareRemote(t)
+	forkRemote := makeBareRemote(t)
+
+	src := t.TempDir()
+	runCmd(t, src, "git", "clone", "--branch", "develop", apiRemote, filepath.Join(src, "services", "api"))
+	runCmd(t, src, "git", "clone", webRemote, filepath.Join(src, "web"))
+	runCmd(t, filepath.Join(src, "web"), "git", "remote", "add", "upstream", forkRemote)
+
+	groups := map[string][]string{"backend": {"services/*"}}
+	cfg, err := newConfig(defaultExcludeDirs, nil, nil, nil, 20, false, "origin", groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Output = outputJSON
+
+	var exported bytes.Buffer
+	if err := exportManifest(context.Background(), src, &exported, 2, cfg); err != nil {
+		t.Fatal(err)
+	}
+	manifestFile := filepath.Join(t.TempDir(), "gb.manifest.yaml")
+	if err := os.WriteFile(manifestFile, exported.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := loadManifest(manifestFile)
+	if err != nil {
+		t.Fatalf("exported manifest does not load: %v\n%s", err, exported.String())
+	}
+	if len(m.Repos) != 2 || m.Repos[0].Path != "services/api" || m.Repos[0].Branch != "develop" {
+		t.Fatalf("unexpected manifest: %+v", m.Repos)
+	}
+	if strings.Join(m.Repos[0].Groups, ",") != "backend" || len(m.Repos[1].Groups) != 0 {
+		t.Errorf("unexpected groups: %+v", m.Repos)
+	}
+	if len(m.Repos[1].Remotes) != 2 || m.Repos[1].Remotes[0].Name != "origin" || m.Repos[1].Remotes[1].Name != "upstream" {
+		t.Errorf("unexpected remotes: %+v", m.Repos[1].Remotes)
+	}
+
+	dst := t.TempDir()
+	cloneCfg, err := newConfig(defaultExcludeDirs, nil, nil, nil, 20, false, "origin", m.mergeGroups(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloneCfg.Output = outputJSON
+	if err := runQuiet(t, func() error { return cloneWorkspace(context.Background(), dst, m, 2, cloneCfg) }); err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	if branch, _ := gitOutput(filepath.Join(dst, "services", "api"), "branch", "--show-current"); branch != "develop" {
+		t.Errorf("expected develop checked out, got %q", branch)
+	}
+	if url, _ := gitOutput(filepath.Join(dst, "web"), "remote", "get-url", "upstream"); url != forkRemote {
+		t.Errorf("expected upstream remote %s, got %q", forkRemote, url)
+	}
+
+	var reexported bytes.Buffer
+	if err := exportManifest(context.Background(), dst, &reexported, 2, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if reexported.String() != exported.String() {
+		t.Errorf("manifest did not round-trip:\n%s\nvs\n%s", exported.String(), reexported.String())
+	}
+}
+
+func TestCloneVerifiesExistingRepos(t *testing.T) {
+	remote := makeBareRemote(t)
+	dst := t.TempDir()
+	runCmd(t, dst, "git", "clone", remote, filepath.Join(dst, "app"))
+
+	res := cloneOrVerify(context.Background(), RepoInfo{Path: filepath.Join(dst, "app"), RelPath: "app"}, manifestRepo{
+		Path:    "app",
+		Remotes: []manifestRemote{{Name: "origin", URL: remote}, {Name: "mirror", URL: remote}},
+		Branch:  "main",
+	})
+	if !res.Success || res.Action != "verified" || len(res.Warnings) != 0 {
+		t.Fatalf("expected clean verification, got %+v", res)
+	}
+	if url, _ := gitOutput(filepath.Join(dst, "app"), "remote", "get-url", "mirror"); url != remote {
+		t.Errorf("expected missing remote to be added, got %q", url)
+	}
+}
+
+func TestCloneGroupFilter(t *testing.T) {
+	remote := makeBareRemote(t)
+	m := &Manifest{Version: manifestVersion, Repos: []manifestRepo{
+		{Path: "a", Remotes: []manifestRemote{{Name: "origin", URL: remote}}, Groups: []string{"core"}},
+		{Path: "b", Remotes: []manifestRemote{{Name: "origin", URL: remote}}},
+	}}
+	cfg, err := newConfig(nil, []string{"@core"}, nil, nil, 20, false, "origin", m.mergeGroups(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Output = outputJSON
+
+	dst := t.TempDir()
+	if err := runQuiet(t, func() error { return cloneWorkspace(context.Background(), dst, m, 2, cfg) }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a", ".git")); err != nil {
+		t.Error("expected repo a to be cloned")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "b")); err == nil {
+		t.Error("expected repo b to be filtered out")
+	}
+}
+
+func TestLoadManifestValidation(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]string{
+		"escape":    "version: 1\nrepos:\n  - path: ../x\n    remotes: [{url: /r}]\n",
+		"noremote":  "version: 1\nrepos:\n  - path: x\n",
+		"duplicate": "version: 1\nrepos:\n  - path: x\n    remotes: [{url: /r}]\n  - path: ./x\n    remotes: [{url: /r}]\n",
+		"version":   "version: 9\nrepos: []\n",
+	}
+	for name, content := range cases {
+		p := filepath.Join(dir, name+".yaml")
+		writeFile(t, dir, name+".yaml", content)
+		if _, err := loadManifest(p); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	writeFile(t, dir, "ok.yaml", "version: 1\nrepos:\n  - path: x\n    remotes: [{url: /r}]\n")
+	m, err := loadManifest(filepath.Join(dir, "ok.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Repos[0].Remotes[0].Name != "origin" {
+		t.Errorf("expected first remote to default to origin, got %+v", m.Repos[0].Remotes)
+	}
+}
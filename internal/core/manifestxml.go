@@ -0,0 +1,233 @@
+package core
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type xmlManifest struct {
+	Remotes  []xmlRemote  `xml:"remote"`
+	Default  *xmlDefault  `xml:"default"`
+	Projects []xmlProject `xml:"project"`
+	Includes []xmlInclude `xml:"include"`
+}
+
+type xmlRemote struct {
+	Name     string `xml:"name,attr"`
+	Fetch    string `xml:"fetch,attr"`
+	Revision string `xml:"revision,attr"`
+}
+
+type xmlDefault struct {
+	Remote   string `xml:"remote,attr"`
+	Revision string `xml:"revision,attr"`
+}
+
+type xmlProject struct {
+	Name     string `xml:"name,attr"`
+	Path     string `xml:"path,attr"`
+	Remote   string `xml:"remote,attr"`
+	Revision string `xml:"revision,attr"`
+	Groups   string `xml:"groups,attr"`
+}
+
+type xmlInclude struct {
+	Name string `xml:"name,attr"`
+}
+
+func isXMLManifest(file string, data []byte) bool {
+	return strings.EqualFold(filepath.Ext(file), ".xml") || strings.HasPrefix(strings.TrimSpace(string(data)), "<")
+}
+
+// repoToolTop returns the workspace top for manifests inside a repo tool
+// checkout (<top>/.repo/...), or "" otherwise.
+func repoToolTop(file string) string {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return ""
+	}
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		if filepath.Base(dir) == ".repo" {
+			return filepath.Dir(dir)
+		}
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+	}
+}
+
+func readXMLManifest(file string) (*xmlManifest, error) {
+	merged := &xmlManifest{}
+	visited := make(map[string]bool)
+	var read func(p string) error
+	read = func(p string) error {
+		abs, _ := filepath.Abs(p)
+		if visited[abs] {
+			return fmt.Errorf("manifest %s: include cycle", p)
+		}
+		visited[abs] = true
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		var m xmlManifest
+		if err := xml.Unmarshal(data, &m); err != nil {
+			return fmt.Errorf("manifest %s: %w", p, err)
+		}
+		merged.Remotes = append(merged.Remotes, m.Remotes...)
+		if m.Default != nil {
+			merged.Default = m.Default
+		}
+		merged.Projects = append(merged.Projects, m.Projects...)
+
+		includeDir := filepath.Dir(p)
+		if filepath.Base(includeDir) == ".repo" {
+			includeDir = filepath.Join(includeDir, "manifests")
+		}
+		for _, inc := range m.Includes {
+			if err := read(filepath.Join(includeDir, inc.Name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := read(file); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+func parseXMLManifest(file string) (*Manifest, error) {
+	x, err := readXMLManifest(file)
+	if err != nil {
+		return nil, err
+	}
+
+	remotes := make(map[string]xmlRemote, len(x.Remotes))
+	for _, r := range x.Remotes {
+		remotes[r.Name] = r
+	}
+	def := xmlDefault{}
+	if x.Default != nil {
+		def = *x.Default
+	}
+
+	m := &Manifest{Version: manifestVersion, root: repoToolTop(file)}
+	manifestURL := sync.OnceValue(func() string { return manifestRemoteURL(file) })
+	fetchURLs := make(map[string]string, len(remotes))
+	seen := make(map[string]bool, len(x.Projects))
+	for _, p := range x.Projects {
+		if p.Name == "" {
+			return nil, fmt.Errorf("manifest %s: project without name", file)
+		}
+		relPath := p.Path
+		if relPath == "" {
+			relPath = p.Name
+		}
+		relPath = path.Clean(filepath.ToSlash(relPath))
+		if escapesWorkspace(relPath) {
+			return nil, fmt.Errorf("manifest %s: invalid project path %q", file, relPath)
+		}
+		if seen[relPath] {
+			return nil, fmt.Errorf("manifest %s: duplicate project path %q", file, relPath)
+		}
+		seen[relPath] = true
+
+		remoteName := p.Remote
+		if remoteName == "" {
+			remoteName = def.Remote
+		}
+		remote, ok := remotes[remoteName]
+		if !ok {
+			return nil, fmt.Errorf("manifest %s: project %q uses unknown remote %q", file, p.Name, remoteName)
+		}
+
+		revision := p.Revision
+		if revision == "" {
+			revision = remote.Revision
+		}
+		if revision == "" {
+			revision = def.Revision
+		}
+
+		fetch, ok := fetchURLs[remoteName]
+		if !ok {
+			if fetch, err = resolveFetchURL(remote.Fetch, manifestURL); err != nil {
+				return nil, fmt.Errorf("manifest %s: remote %q: %w", file, remoteName, err)
+			}
+			fetchURLs[remoteName] = fetch
+		}
+
+		groups := strings.FieldsFunc(p.Groups, func(r rune) bool { return r == ',' || r == ' ' })
+
+		m.Repos = append(m.Repos, manifestRepo{
+			Path:    relPath,
+			Remotes: []manifestRemote{{Name: remoteName, URL: fetch + "/" + p.Name}},
+			Branch:  strings.TrimPrefix(revision, "refs/heads/"),
+			Groups:  groups,
+		})
+	}
+	return m, nil
+}
+
+// manifestRemoteURL returns the URL the manifest's own repository was cloned
+// from, which relative fetch values are resolved against, or "".
+func manifestRemoteURL(file string) string {
+	dir := filepath.Dir(file)
+	if filepath.Base(dir) == ".repo" {
+		dir = filepath.Join(dir, "manifests")
+	}
+	u, err := gitOutput(dir, "remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+	return u
+}
+
+// resolveFetchURL resolves a relative fetch value such as ".." against the
+// manifest's URL the way repo does, which takes everything up to the first
+// slash of an scp-style URL as the host.
+func resolveFetchURL(fetch string, manifestURL func() string) (string, error) {
+	fetch = strings.TrimSuffix(fetch, "/")
+	if !isRelativeURL(fetch) {
+		return fetch, nil
+	}
+	base := strings.TrimSuffix(manifestURL(), "/")
+	if base == "" {
+		return "", fmt.Errorf("relative fetch %q needs the manifest repository's remote URL", fetch)
+	}
+
+	start := -1
+	if i := strings.Index(base, "://"); i >= 0 {
+		start = i + len("://")
+	} else if isSCPURL(base) {
+		start = 0
+	}
+	host := 0
+	if start >= 0 {
+		host = len(base)
+		if i := strings.Index(base[start:], "/"); i >= 0 {
+			host = start + i
+		}
+	}
+	resolved := (&url.URL{Path: base[host:]}).ResolveReference(&url.URL{Path: fetch}).Path
+	return strings.TrimSuffix(base[:host]+resolved, "/"), nil
+}
+
+func isSCPURL(u string) bool {
+	colon, slash := strings.Index(u, ":"), strings.Index(u, "/")
+	return colon > 1 && (slash < 0 || colon < slash) && !strings.Contains(u, "://")
+}
+
+// isRelativeURL reports whether u is neither a URL, an scp-style host:path
+// nor an absolute local path.
+func isRelativeURL(u string) bool {
+	return !strings.Contains(u, "://") && !isSCPURL(u) && !filepath.IsAbs(u) && !strings.HasPrefix(u, "/")
+}
@@ -0,0 +1,270 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testXMLManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<manifest>
+  <remote name="aosp" fetch="https://example.com/aosp/" />
+  <remote name="corp" fetch="https://corp.example.com" revision="refs/heads/stable" />
+  <default remote="aosp" revision="refs/heads/main" />
+  <project name="platform/build" path="build" groups="core,tools" />
+  <project name="device/widget" remote="corp" groups="devices" />
+  <project name="platform/art" path="art" revision="release-1" />
+  <include name="extra.xml" />
+</manifest>
+`
+
+const testXMLInclude = `<manifest>
+  <project name="platform/docs" path="docs" groups="docs" />
+</manifest>
+`
+
+func TestParseXMLManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "default.xml", testXMLManifest)
+	writeFile(t, dir, "extra.xml", testXMLInclude)
+
+	m, err := readManifest(filepath.Join(dir, "default.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Repos) != 4 {
+		t.Fatalf("expected 4 projects, got %+v", m.Repos)
+	}
+
+	want := map[string]struct{ remote, url, branch, groups string }{
+		"build":         {"aosp", "https://example.com/aosp/platform/build", "main", "core,tools"},
+		"device/widget": {"corp", "https://corp.example.com/device/widget", "stable", "devices"},
+		"art":           {"aosp", "https://example.com/aosp/platform/art", "release-1", ""},
+		"docs":          {"aosp", "https://example.com/aosp/platform/docs", "main", "docs"},
+	}
+	for _, r := range m.Repos {
+		w, ok := want[r.Path]
+		if !ok {
+			t.Errorf("unexpected project path %q", r.Path)
+			continue
+		}
+		if r.Remotes[0].Name != w.remote || r.Remotes[0].URL != w.url || r.Branch != w.branch || strings.Join(r.Groups, ",") != w.groups {
+			t.Errorf("%s: got %+v", r.Path, r)
+		}
+	}
+}
+
+func TestParseXMLManifestUnknownRemote(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "bad.xml", `<manifest><project name="x" remote="nope" /></manifest>`)
+	if _, err := readManifest(filepath.Join(dir, "bad.xml")); err == nil {
+		t.Error("expected error for unknown remote")
+	}
+}
+
+func TestParseXMLManifestRejectsEscapingPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, p := range []string{"/etc/app", "../app", "a/../../app"} {
+		writeFile(t, dir, "bad.xml", `<manifest><remote name="r" fetch="https://example.com" /><default remote="r" />`+
+			`<project name="app" path="`+p+`" /></manifest>`)
+		if _, err := readManifest(filepath.Join(dir, "bad.xml")); err == nil {
+			t.Errorf("expected error for project path %q", p)
+		}
+	}
+}
+
+func TestResolveFetchURL(t *testing.T) {
+	tests := []struct{ fetch, manifest, want string }{
+		{"https://example.com/aosp/", "", "https://example.com/aosp"},
+		{"git@example.com:aosp", "", "git@example.com:aosp"},
+		{"..", "https://example.com/platform/manifest", "https://example.com"},
+		{".", "https://example.com/platform/manifest.git/", "https://example.com/platform"},
+		{"../mirror", "ssh://git@example.com:29418/platform/manifest", "ssh://git@example.com:29418/mirror"},
+		{"..", "git@example.com:platform/manifest", "git@example.com:platform"},
+		{"..", "/srv/git/platform/manifest", "/srv/git"},
+	}
+	for _, tt := range tests {
+		got, err := resolveFetchURL(tt.fetch, func() string { return tt.manifest })
+		if err != nil || got != tt.want {
+			t.Errorf("resolveFetchURL(%q, %q) = %q, %v; want %q", tt.fetch, tt.manifest, got, err, tt.want)
+		}
+	}
+	if _, err := resolveFetchURL("..", func() string { return "" }); err == nil {
+		t.Error("expected an error for a relative fetch without a manifest URL")
+	}
+}
+
+func TestCloneXMLManifestIntoRepoToolTop(t *testing.T) {
+	mirror := t.TempDir()
+	runCmd(t, mirror, "git", "clone", "--bare", makeBareRemote(t), filepath.Join(mirror, "tools", "app.git"))
+
+	top := t.TempDir()
+	manifests := filepath.Join(top, ".repo", "manifests")
+	createGitRepo(t, manifests)
+	runCmd(t, manifests, "git", "remote", "add", "origin", filepath.Join(mirror, "platform", "manifest"))
+	writeFile(t, manifests, "default.xml", `<manifest><remote name="origin" fetch=".." /><default remote="origin" revision="main" />`+
+		`<project name="tools/app.git" path="app" /></manifest>`)
+
+	m, err := readManifest(filepath.Join(manifests, "default.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+	if err := runQuiet(t, func() error { return cloneWorkspace(context.Background(), t.TempDir(), m, 2, cfg) }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(top, "app", ".git")); err != nil {
+		t.Errorf("expected app to be cloned under the repo tool top: %v", err)
+	}
+}
+
+func TestRepoToolTop(t *testing.T) {
+	top := t.TempDir()
+	manifests := filepath.Join(top, ".repo", "manifests")
+	createDir(t, manifests)
+	if got := repoToolTop(filepath.Join(manifests, "default.xml")); got != top {
+		t.Errorf("expected %s, got %s", top, got)
+	}
+	if got := repoToolTop(filepath.Join(top, "default.xml")); got != "" {
+		t.Errorf("expected no top outside .repo, got %s", got)
+	}
+}
+
+func TestDiscoverReposFromManifest(t *testing.T) {
+	root := t.TempDir()
+	createGitRepo(t, filepath.Join(root, "build"))
+	createGitRepo(t, filepath.Join(root, "docs"))
+	createGitRepo(t, filepath.Join(root, "unlisted"))
+	writeFile(t, root, "default.xml", testXMLManifest)
+	writeFile(t, root, "extra.xml", testXMLInclude)
+
+	m, err := readManifest(filepath.Join(root, "default.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := newConfig(nil, []string{"@core", "@docs"}, nil, nil, 20, false, "origin", m.mergeGroups(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.manifest = m
+	cfg.Output = outputJSON
+
+	repos, total := discoverRepos(root, 2, cfg, false)
+	if total != 2 {
+		t.Errorf("expected 2 checked-out manifest repos, got %d", total)
+	}
+	var rels []string
+	for _, r := range repos {
+		rels = append(rels, r.RelPath)
+	}
+	if strings.Join(rels, ",") != "build,docs" {
+		t.Errorf("unexpected repos: %v", rels)
+	}
+}
+
+func TestSyncBranchUsesManifestRemoteAndRevision(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	bare := makeBareRemote(t, "develop")
+
+	pusher := filepath.Join(t.TempDir(), "pusher")
+	runCmd(t, filepath.Dir(pusher), "git", "clone", "--branch", "develop", bare, pusher)
+	runCmd(t, pusher, "git", "config", "user.name", "test")
+	runCmd(t, pusher, "git", "config", "user.email", "test@test.com")
+	writeFile(t, pusher, "new.txt", "new")
+	runCmd(t, pusher, "git", "add", ".")
+	runCmd(t, pusher, "git", "commit", "-m", "develop work")
+	runCmd(t, pusher, "git", "push", "origin", "develop")
+	want, _ := gitOutput(pusher, "rev-parse", "HEAD")
+
+	root := t.TempDir()
+	runCmd(t, root, "git", "clone", "--origin", "corp", bare, filepath.Join(root, "app"))
+	writeFile(t, root, "default.xml", `<manifest>
+  <remote name="corp" fetch="`+filepath.Dir(bare)+`" />
+  <default remote="corp" revision="develop" />
+  <project name="remote.git" path="app" />
+</manifest>`)
+
+	m, err := readManifest(filepath.Join(root, "default.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	cfg.manifest = m
+	cfg.Output = outputJSON
+
+	if err := runQuiet(t, func() error { return syncBranch(context.Background(), root, "", "soft", 2, cfg) }); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if got, _ := gitOutput(filepath.Join(root, "app"), "rev-parse", "HEAD"); got != want {
+		t.Errorf("expected HEAD at corp/develop %s, got %s", want, got)
+	}
+}
+
+func TestSyncBranchRequiresBranchWithoutManifest(t *testing.T) {
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	if err := syncBranch(context.Background(), t.TempDir(), "", "soft", 1, cfg); err == nil {
+		t.Error("expected error when no branch and no manifest")
+	}
+}
+
+func TestInjectOptionalValues(t *testing.T) {
+	got := injectOptionalValues([]string{"-m", "default.xml", "-rs", "-i", "x"})
+	if strings.Join(got, "|") != "-m|default.xml|-rs||-i|x" {
+		t.Errorf("unexpected args: %q", got)
+	}
+	if got := injectOptionalValues([]string{"-rh", "main"}); len(got) != 2 {
+		t.Errorf("expected value to be kept, got %q", got)
+	}
+}
+
+func TestXMLManifestPinnedRevisions(t *testing.T) {
+	seed := t.TempDir()
+	createGitRepo(t, seed)
+	pinned, _ := gitOutput(seed, "rev-parse", "HEAD")
+	runCmd(t, seed, "git", "tag", "v1")
+	runCmd(t, seed, "git", "commit", "--allow-empty", "-m", "after v1")
+	bare := filepath.Join(t.TempDir(), "remote.git")
+	runCmd(t, seed, "git", "clone", "--bare", seed, bare)
+
+	root := t.TempDir()
+	writeFile(t, root, "default.xml", `<manifest>
+  <remote name="origin" fetch="`+filepath.Dir(bare)+`" />
+  <default remote="origin" revision="main" />
+  <project name="remote.git" path="tagged" revision="refs/tags/v1" />
+  <project name="remote.git" path="pinned" revision="`+pinned+`" />
+</manifest>`)
+	m, err := readManifest(filepath.Join(root, "default.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	cfg.manifest = m
+	cfg.Output = outputJSON
+
+	if err := runQuiet(t, func() error { return cloneWorkspace(context.Background(), root, m, 2, cfg) }); err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	for _, dir := range []string{"tagged", "pinned"} {
+		head, _ := gitOutput(filepath.Join(root, dir), "rev-parse", "HEAD")
+		branch, _ := gitOutput(filepath.Join(root, dir), "branch", "--show-current")
+		if head != pinned || branch != "" {
+			t.Errorf("%s: expected a detached HEAD at %s, got %s on %q", dir, pinned, head, branch)
+		}
+	}
+
+	pinnedDir := filepath.Join(root, "pinned")
+	if res := cfg.resetOne(context.Background(), RepoInfo{Path: pinnedDir, RelPath: "pinned"}, "", "hard", nil, nil); !res.Skipped || !strings.Contains(res.SkipReason, "pins") {
+		t.Errorf("expected a reset to skip the pinned repo, got %+v", res)
+	}
+
+	runCmd(t, pinnedDir, "git", "switch", "main")
+	if err := runQuiet(t, func() error { return switchBranches(context.Background(), root, "", 2, cfg) }); err != nil {
+		t.Fatalf("switch failed: %v", err)
+	}
+	if head, _ := gitOutput(pinnedDir, "rev-parse", "HEAD"); head != pinned {
+		t.Errorf("expected switch to detach at the pinned %s, got %s", pinned, head)
+	}
+}
@@ -0,0 +1,291 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+)
+
+const (
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+	outputSchema = "gb/v1"
+)
+
+type repoResult interface {
+	repoPath() string
+	outcome() (state, message string)
+}
+
+type outputRecord struct {
+	Schema string `json:"schema"`
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	Result any    `json:"result"`
+}
+
+type outputDocument struct {
+	Schema  string         `json:"schema"`
+	Kind    string         `json:"kind"`
+	Summary map[string]int `json:"summary"`
+	Results []outputRecord `json:"results"`
+}
+
+type resultWriter struct {
+	format  string
+	kind    string
+	w       io.Writer
+	records []outputRecord
+	relPath []string
+	err     error
+}
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case "", outputJSON, outputNDJSON:
+		return nil
+	}
+	return fmt.Errorf("invalid output format %q (want %s or %s)", format, outputJSON, outputNDJSON)
+}
+
+func newResultWriter(format, kind string) *resultWriter {
+	return &resultWriter{format: format, kind: kind, w: os.Stdout}
+}
+
+func (rw *resultWriter) enabled() bool {
+	return rw.format != ""
+}
+
+func (rw *resultWriter) add(res repoResult) {
+	if !rw.enabled() {
+		return
+	}
+	state, _ := res.outcome()
+	rec := outputRecord{Schema: outputSchema, Kind: rw.kind, Status: state, Result: res}
+	if rw.format == outputNDJSON {
+		if err := json.NewEncoder(rw.w).Encode(rec); err != nil && rw.err == nil {
+			rw.err = err
+		}
+		return
+	}
+	rw.records = append(rw.records, rec)
+	rw.relPath = append(rw.relPath, res.repoPath())
+}
+
+func (rw *resultWriter) close() error {
+	if rw.err != nil || rw.format != outputJSON {
+		return rw.err
+	}
+	idx := make([]int, len(rw.records))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return rw.relPath[idx[a]] < rw.relPath[idx[b]] })
+
+	doc := outputDocument{Schema: outputSchema, Kind: rw.kind, Summary: make(map[string]int), Results: make([]outputRecord, 0, len(idx))}
+	for _, i := range idx {
+		doc.Results = append(doc.Results, rw.records[i])
+		doc.Summary[rw.records[i].Status]++
+	}
+	enc := json.NewEncoder(rw.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func (rw *resultWriter) finish(failed bool) error {
+	if err := rw.close(); err != nil {
+		return err
+	}
+	if failed {
+		return errReposFailed
+	}
+	return nil
+}
+
+func (rw *resultWriter) finishRun(failed, cancelled int) error {
+	if err := rw.close(); err != nil {
+		return err
+	}
+	return runError(failed, cancelled)
+}
+
+// runError reports a run cut short by --fail-fast/--max-failures as
+// cancelled, which takes precedence over the failures that triggered it.
+func runError(failed, cancelled int) error {
+	switch {
+	case cancelled > 0:
+		return errReposCancelled
+	case failed > 0:
+		return errReposFailed
+	}
+	return nil
+}
+
+func skippedNote(skipped int) string {
+	if skipped == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", %s skipped", StyleSkipped.Render(fmt.Sprintf("%d", skipped)))
+}
+
+func cancelledNote(cancelled int) string {
+	if cancelled == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", %s cancelled", StyleDim.Render(fmt.Sprintf("%d", cancelled)))
+}
+
+func (cfg *Config) infoWriter() io.Writer {
+	if cfg.Output != "" || cfg.infoToStderr {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func exitCodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+func (r BranchResult) repoPath() string { return r.RelPath }
+
+func (r BranchResult) outcome() (string, string) {
+	if r.Error != nil {
+		return statusFailed, r.Error.Error()
+	}
+	return statusCompleted, ""
+}
+
+func (r BranchResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		RelPath    string `json:"relPath"`
+		Branch     string `json:"branch"`
+		Error      string `json:"error"`
+		DurationMs int64  `json:"durationMs"`
+	}{r.RelPath, r.Branch, errString(r.Error), r.DurationMs})
+}
+
+func (r CommandResult) repoPath() string { return r.RelPath }
+
+func (r CommandResult) outcome() (string, string) {
+	switch {
+	case r.Cancelled:
+		return statusCancelled, ""
+	case r.Skipped:
+		return statusSkipped, ""
+	case r.Error != nil:
+		return statusFailed, r.Error.Error()
+	}
+	return statusCompleted, ""
+}
+
+func (r CommandResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		RelPath    string `json:"relPath"`
+		Output     string `json:"output"`
+		Error      string `json:"error"`
+		ExitCode   int    `json:"exitCode"`
+		Retries    int    `json:"retries"`
+		Skipped    bool   `json:"skipped"`
+		Cancelled  bool   `json:"cancelled"`
+		DurationMs int64  `json:"durationMs"`
+	}{r.RelPath, r.Output, errString(r.Error), r.ExitCode, r.Retries, r.Skipped, r.Cancelled, r.DurationMs})
+}
+
+func (r CommandResult) retryCount() int { return r.Retries }
+
+func (CommandResult) cancelledResult(r RepoInfo) CommandResult {
+	return CommandResult{RelPath: r.RelPath, Cancelled: true}
+}
+
+func (r SwitchResult) repoPath() string { return r.RelPath }
+
+func (r SwitchResult) outcome() (string, string) {
+	switch {
+	case r.Cancelled:
+		return statusCancelled, ""
+	case r.Skipped:
+		return statusSkipped, r.Error
+	case r.Success:
+		return statusCompleted, ""
+	}
+	return statusFailed, r.Error
+}
+
+func (SwitchResult) cancelledResult(r RepoInfo) SwitchResult {
+	return SwitchResult{RelPath: r.RelPath, Cancelled: true}
+}
+
+func (r ResetResult) repoPath() string { return r.RelPath }
+
+func (r ResetResult) outcome() (string, string) {
+	switch {
+	case r.Cancelled:
+		return statusCancelled, ""
+	case r.Skipped:
+		return statusSkipped, r.SkipReason
+	case r.Success:
+		return statusCompleted, r.Warning
+	}
+	return statusFailed, r.Error
+}
+
+func (ResetResult) cancelledResult(r RepoInfo) ResetResult {
+	return ResetResult{RelPath: r.RelPath, Cancelled: true}
+}
+
+func (r DivergeResult) repoPath() string { return r.RelPath }
+
+func (r DivergeResult) outcome() (string, string) {
+	switch {
+	case r.Error != "":
+		return statusFailed, r.Error
+	case r.Skipped:
+		return statusSkipped, r.SkipReason
+	}
+	return statusCompleted, ""
+}
+
+func (r TrackResult) repoPath() string { return r.RelPath }
+
+func (r TrackResult) outcome() (string, string) {
+	if r.Error != "" {
+		return statusFailed, r.Error
+	}
+	return statusCompleted, ""
+}
+
+func (r WorktreeListResult) repoPath() string { return r.RelPath }
+
+func (r WorktreeListResult) outcome() (string, string) {
+	if r.Error != "" {
+		return statusFailed, r.Error
+	}
+	return statusCompleted, ""
+}
+
+func (r WorktreePathResult) repoPath() string { return r.RelPath }
+
+func (r WorktreePathResult) outcome() (string, string) {
+	if !r.Exists {
+		return statusSkipped, "no worktree"
+	}
+	return statusCompleted, ""
+}
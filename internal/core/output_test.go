@@ -0,0 +1,165 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateOutputFormat(t *testing.T) {
+	for _, f := range []string{"", "json", "ndjson"} {
+		if err := validateOutputFormat(f); err != nil {
+			t.Errorf("expected %q to be valid, got %v", f, err)
+		}
+	}
+	if err := validateOutputFormat("yaml"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestResultWriterJSONDocument(t *testing.T) {
+	var buf bytes.Buffer
+	rw := newResultWriter(outputJSON, "switch")
+	rw.w = &buf
+
+	rw.add(SwitchResult{RelPath: "b", Success: true})
+	rw.add(SwitchResult{RelPath: "a", Error: "switch failed"})
+	rw.add(SwitchResult{RelPath: "c", Skipped: true, Error: "branch locked in worktree"})
+	if err := rw.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Schema  string         `json:"schema"`
+		Kind    string         `json:"kind"`
+		Summary map[string]int `json:"summary"`
+		Results []struct {
+			Status string       `json:"status"`
+			Result SwitchResult `json:"result"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if doc.Schema != outputSchema || doc.Kind != "switch" {
+		t.Errorf("unexpected envelope: %+v", doc)
+	}
+	if len(doc.Results) != 3 || doc.Results[0].Result.RelPath != "a" {
+		t.Fatalf("expected results sorted by relPath, got %+v", doc.Results)
+	}
+	if doc.Results[0].Status != statusFailed || doc.Results[2].Status != statusSkipped {
+		t.Errorf("unexpected statuses: %+v", doc.Results)
+	}
+	if doc.Summary[statusCompleted] != 1 || doc.Summary[statusFailed] != 1 || doc.Summary[statusSkipped] != 1 {
+		t.Errorf("unexpected summary: %v", doc.Summary)
+	}
+}
+
+func TestResultWriterNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	rw := newResultWriter(outputNDJSON, "command")
+	rw.w = &buf
+
+	rw.add(CommandResult{RelPath: "a", Output: "ok\n"})
+	rw.add(CommandResult{RelPath: "b", Error: errors.New("exit status 1"), ExitCode: 1})
+	if err := rw.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %s", len(lines), buf.String())
+	}
+	var rec struct {
+		Status string `json:"status"`
+		Result struct {
+			RelPath  string `json:"relPath"`
+			Error    string `json:"error"`
+			ExitCode int    `json:"exitCode"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Status != statusFailed || rec.Result.Error != "exit status 1" || rec.Result.ExitCode != 1 {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+}
+
+func TestResultWriterDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	rw := newResultWriter("", "switch")
+	rw.w = &buf
+	rw.add(SwitchResult{RelPath: "a"})
+	if err := rw.finish(true); !errors.Is(err, errReposFailed) {
+		t.Errorf("expected errReposFailed, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output when disabled, got %s", buf.String())
+	}
+}
+
+func TestExitCodeOf(t *testing.T) {
+	if exitCodeOf(nil) != 0 {
+		t.Error("expected 0 for nil error")
+	}
+	err := exec.Command("git", "invalid-subcommand-that-does-not-exist").Run()
+	if code := exitCodeOf(err); code <= 0 {
+		t.Errorf("expected positive exit code, got %d", code)
+	}
+	if exitCodeOf(errors.New("boom")) != -1 {
+		t.Error("expected -1 for non-exit error")
+	}
+}
+
+func TestExecuteCommandInReposJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	createGitRepo(t, filepath.Join(tmpDir, "repo1"))
+	createGitRepo(t, filepath.Join(tmpDir, "repo2"))
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	runErr := executeCommandInRepos(context.Background(), tmpDir, []string{"rev-parse", "--abbrev-ref", "HEAD"}, 2, cfg)
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+	outBytes, _ := io.ReadAll(r)
+
+	if runErr != nil {
+		t.Fatalf("unexpected error: %v", runErr)
+	}
+
+	var doc struct {
+		Kind    string `json:"kind"`
+		Results []struct {
+			Status string `json:"status"`
+			Result struct {
+				RelPath string `json:"relPath"`
+				Output  string `json:"output"`
+			} `json:"result"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(outBytes, &doc); err != nil {
+		t.Fatalf("stdout is not a JSON document: %v\n%s", err, outBytes)
+	}
+	if doc.Kind != "command" || len(doc.Results) != 2 {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+	for _, rec := range doc.Results {
+		if rec.Status != statusCompleted || strings.TrimSpace(rec.Result.Output) != "main" {
+			t.Errorf("unexpected record: %+v", rec)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	progressTUI   = "tui"
+	progressPlain = "plain"
+	progressQuiet = "quiet"
+	progressNone  = "none"
+)
+
+const plainHeartbeatInterval = 30 * time.Second
+
+func validateProgressMode(mode string) error {
+	switch mode {
+	case "", progressTUI, progressPlain, progressQuiet, progressNone:
+		return nil
+	}
+	return fmt.Errorf("invalid progress mode %q (want %s, %s, %s or %s)", mode, progressTUI, progressPlain, progressQuiet, progressNone)
+}
+
+type plainRepo struct {
+	state   string
+	message string
+	start   time.Time
+	end     time.Time
+}
+
+// plainProgress renders progress as timestamped lines for logs and CI. In
+// quiet mode it only prints failures, the heartbeat and the final table.
+type plainProgress struct {
+	w         io.Writer
+	verbose   bool
+	heartbeat time.Duration
+	now       func() time.Time
+	expected  map[string]time.Duration
+	workers   int
+
+	mu    sync.Mutex
+	repos map[string]*plainRepo
+	order []string
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+func newPlainProgress(w io.Writer, repos []RepoInfo, verbose bool) *plainProgress {
+	p := &plainProgress{
+		w:         w,
+		verbose:   verbose,
+		heartbeat: plainHeartbeatInterval,
+		now:       time.Now,
+		repos:     make(map[string]*plainRepo, len(repos)),
+		order:     make([]string, 0, len(repos)),
+	}
+	for _, r := range repos {
+		p.repos[r.RelPath] = &plainRepo{state: statusWaiting}
+		p.order = append(p.order, r.RelPath)
+	}
+	return p
+}
+
+func (p *plainProgress) start() {
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.mu.Lock()
+				p.printf("%s\n", p.heartbeatLine())
+				p.mu.Unlock()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (p *plainProgress) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(p.w, StyleDim.Render(p.now().Format("15:04:05"))+" "+format, args...)
+}
+
+func (p *plainProgress) update(relPath, state, message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, ok := p.repos[relPath]
+	if !ok {
+		r = &plainRepo{}
+		p.repos[relPath] = r
+		p.order = append(p.order, relPath)
+	}
+	r.state, r.message = state, message
+
+	switch state {
+	case statusWaiting:
+		return
+	case statusProcessing:
+		r.start = p.now()
+		if p.verbose {
+			p.printf("%s %s\n", StyleProcessing.Render("start    "), relPath)
+		}
+		return
+	}
+
+	r.end = p.now()
+	line := relPath
+	if !r.start.IsZero() {
+		line += "  " + StyleDim.Render(formatDuration(r.end.Sub(r.start)))
+	}
+	if message != "" {
+		line += "  " + strings.ReplaceAll(message, "\n", " ")
+	}
+	switch state {
+	case statusFailed:
+		p.printf("%s %s\n", StyleFailed.Render("FAILED   "), line)
+	case statusCompleted:
+		if p.verbose {
+			p.printf("%s %s\n", StyleSuccess.Render("ok       "), line)
+		}
+	case statusSkipped:
+		if p.verbose {
+			p.printf("%s %s\n", StyleSkipped.Render("skipped  "), line)
+		}
+	case statusCancelled:
+		if p.verbose {
+			p.printf("%s %s\n", StyleDim.Render("cancelled"), line)
+		}
+	}
+}
+
+// heartbeatLine summarises the run so far, naming the longest-running repo.
+func (p *plainProgress) heartbeatLine() string {
+	finished, failed, running := 0, 0, 0
+	var slowest string
+	var slowestStart time.Time
+	var remaining, waiting []time.Duration
+	for _, relPath := range p.order {
+		r := p.repos[relPath]
+		switch r.state {
+		case statusWaiting:
+			waiting = append(waiting, p.expected[relPath])
+		case statusCompleted, statusSkipped, statusCancelled:
+			finished++
+		case statusFailed:
+			finished++
+			failed++
+		case statusProcessing:
+			running++
+			remaining = append(remaining, max(p.expected[relPath]-p.now().Sub(r.start), 0))
+			if slowest == "" || r.start.Before(slowestStart) {
+				slowest, slowestStart = relPath, r.start
+			}
+		}
+	}
+	line := fmt.Sprintf("%d/%d done, %d failed, %d running", finished, len(p.order), failed, running)
+	if slowest != "" {
+		line += fmt.Sprintf(" (slowest: %s %s)", slowest, p.now().Sub(slowestStart).Round(time.Second))
+	}
+	if p.expected != nil {
+		if eta, ok := estimateRemaining(p.workers, remaining, waiting); ok {
+			line += ", " + formatETA(eta)
+		}
+	}
+	return line
+}
+
+// finish stops the heartbeat and prints every repo's outcome, failures first.
+func (p *plainProgress) finish() {
+	if p.stop != nil {
+		close(p.stop)
+		<-p.done
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	order := append([]string{}, p.order...)
+	sort.SliceStable(order, func(i, j int) bool {
+		return statusPriority[p.repos[order[i]].state] < statusPriority[p.repos[order[j]].state]
+	})
+	width := len("REPO")
+	for _, relPath := range order {
+		width = max(width, len(relPath))
+	}
+
+	_, _ = fmt.Fprintln(p.w)
+	_, _ = fmt.Fprintln(p.w, StyleBold.Render(fmt.Sprintf("%-10s %-9s %-*s  %s", "STATUS", "DURATION", width, "REPO", "MESSAGE")))
+	for _, relPath := range order {
+		r := p.repos[relPath]
+		duration := "-"
+		if !r.start.IsZero() && !r.end.IsZero() {
+			duration = formatDuration(r.end.Sub(r.start))
+		}
+		line := strings.TrimRight(fmt.Sprintf("%-10s %-9s %-*s  %s", r.state, duration, width, relPath, strings.ReplaceAll(r.message, "\n", " ")), " ")
+		if r.state == statusFailed {
+			line = StyleFailed.Render(line)
+		}
+		_, _ = fmt.Fprintln(p.w, line)
+	}
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestPlainProgress(verbose bool) (*plainProgress, *bytes.Buffer, *time.Time) {
+	var buf bytes.Buffer
+	p := newPlainProgress(&buf, []RepoInfo{{RelPath: "api"}, {RelPath: "web"}, {RelPath: "cli"}}, verbose)
+	clock := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	p.now = func() time.Time { return clock }
+	return p, &buf, &clock
+}
+
+func TestPlainProgressLinesAndTable(t *testing.T) {
+	p, buf, clock := newTestPlainProgress(true)
+
+	p.update("api", statusProcessing, "")
+	p.update("web", statusProcessing, "")
+	*clock = clock.Add(1500 * time.Millisecond)
+	p.update("api", statusCompleted, "")
+	*clock = clock.Add(2 * time.Minute)
+	if got := p.heartbeatLine(); got != "1/3 done, 0 failed, 1 running (slowest: web 2m2s)" {
+		t.Errorf("unexpected heartbeat %q", got)
+	}
+	p.update("web", statusFailed, "exit status 1")
+	p.update("cli", statusCancelled, "")
+	p.finish()
+
+	out := buf.String()
+	for _, want := range []string{
+		"15:04:05 start     api\n",
+		"15:04:06 ok        api  1.5s\n",
+		"15:06:06 FAILED    web  2m1.5s  exit status 1\n",
+		"15:06:06 cancelled cli\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	table := out[strings.Index(out, "STATUS"):]
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[1], "failed     2m1.5s    web   exit status 1") || !strings.HasPrefix(lines[2], "cancelled  -") {
+		t.Errorf("expected failures first in the final table:\n%s", table)
+	}
+}
+
+func TestPlainProgressQuietOnlyPrintsFailures(t *testing.T) {
+	p, buf, _ := newTestPlainProgress(false)
+	p.update("api", statusProcessing, "")
+	p.update("api", statusCompleted, "")
+	p.update("web", statusProcessing, "")
+	p.update("web", statusFailed, "boom")
+	out := buf.String()
+	if strings.Contains(out, "start") || strings.Contains(out, "ok ") || !strings.Contains(out, "FAILED    web") {
+		t.Errorf("expected only the failure line, got:\n%s", out)
+	}
+}
+
+func TestRunRejectsUnknownProgressMode(t *testing.T) {
+	err := Run(context.Background(), []string{"--progress=fancy", "-l"})
+	if err == nil || !strings.Contains(err.Error(), "invalid progress mode") {
+		t.Errorf("expected invalid progress mode error, got %v", err)
+	}
+}
@@ -2,42 +2,356 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+type poolOptions[R any] struct {
+	onResult    func(R)
+	failed      func(R) bool
+	cancelled   func(RepoInfo) R
+	maxFailures int
+	control     *runControl
+	expected    map[string]time.Duration
+}
+
+// runControl lets the progress TUI act on the pool it is showing: requeue a
+// repo that has finished, cancel one that is running, and read its log.
+type runControl struct {
+	mu       sync.Mutex
+	attached bool
+	requeues chan RepoInfo
+	wake     chan struct{}
+	repos    map[string]RepoInfo
+	running  map[string]context.CancelFunc
+	finished map[string]bool
+	active   int
+	logs     *LogManager
+
+	// confirmRequeue makes the TUI ask before re-running a repo, for
+	// operations that discard work. cancelNote says what a cancel can't stop.
+	confirmRequeue bool
+	cancelNote     string
+}
+
+func newRunControl() *runControl {
+	return &runControl{wake: make(chan struct{}, 1)}
+}
+
+func (c *runControl) attach(repos []RepoInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attached = true
+	c.requeues = make(chan RepoInfo, len(repos))
+	c.repos = make(map[string]RepoInfo, len(repos))
+	for _, r := range repos {
+		c.repos[r.RelPath] = r
+	}
+	c.running = make(map[string]context.CancelFunc)
+	c.finished = make(map[string]bool)
+	c.active = 0
+}
+
+// detachIfIdle stops accepting requeues once nothing is running or queued,
+// so a requeue can't slip in after the pool has decided it is done.
+func (c *runControl) detachIfIdle() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.active > 0 || len(c.requeues) > 0 {
+		return false
+	}
+	c.attached = false
+	return true
+}
+
+func (c *runControl) detach() {
+	c.mu.Lock()
+	c.attached = false
+	c.mu.Unlock()
+}
+
+func (c *runControl) dispatched() {
+	c.mu.Lock()
+	c.active++
+	c.mu.Unlock()
+}
+
+func (c *runControl) start(relPath string, cancel context.CancelFunc) {
+	c.mu.Lock()
+	c.running[relPath] = cancel
+	c.mu.Unlock()
+}
+
+func (c *runControl) finish(relPath string) {
+	c.mu.Lock()
+	delete(c.running, relPath)
+	c.finished[relPath] = true
+	c.active--
+	c.mu.Unlock()
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// requeue puts a finished repo back on the pool's queue.
+func (c *runControl) requeue(relPath string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.attached || !c.finished[relPath] {
+		return false
+	}
+	delete(c.finished, relPath)
+	c.requeues <- c.repos[relPath]
+	return true
+}
+
+// cancel stops a running repo's commands; the repo is reported as cancelled
+// unless it finishes successfully anyway.
+func (c *runControl) cancel(relPath string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cancel, ok := c.running[relPath]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+func (c *runControl) readLog(relPath string) (string, error) {
+	if c == nil || c.logs == nil {
+		return "", fmt.Errorf("no log for %s", relPath)
+	}
+	return c.logs.ReadLog(relPath)
+}
+
+// cancellable results can stand in for a repo the pool never started.
+type cancellable[R any] interface {
+	repoResult
+	cancelledResult(RepoInfo) R
+}
+
+func newPoolOptions[R repoResult](out *resultWriter) poolOptions[R] {
+	return poolOptions[R]{
+		onResult: func(res R) { out.add(res) },
+	}
+}
+
+// newRunOptions is newPoolOptions for operations that change repos: it stops
+// dispatching after cfg.MaxFailures failures and reports the repos it never
+// started as cancelled.
+func newRunOptions[R cancellable[R]](cfg *Config, out *resultWriter, progress *ProgressState) poolOptions[R] {
+	var zero R
+	return poolOptions[R]{
+		onResult: func(res R) {
+			if state, _ := res.outcome(); state == statusCancelled {
+				progress.UpdateStatus(res.repoPath(), statusCancelled, "")
+			}
+			out.add(res)
+		},
+		failed: func(res R) bool {
+			state, _ := res.outcome()
+			return state == statusFailed
+		},
+		cancelled:   zero.cancelledResult,
+		maxFailures: cfg.MaxFailures,
+		control:     progress.control,
+		expected:    progress.expected,
+	}
+}
+
+// withLogs also records each result in the run's log store.
+func (o poolOptions[R]) withLogs(lm *LogManager) poolOptions[R] {
+	if o.control != nil {
+		o.control.logs = lm
+	}
+	next := o.onResult
+	o.onResult = func(res R) {
+		if rr, ok := any(res).(repoResult); ok {
+			lm.recordResult(rr)
+		}
+		if next != nil {
+			next(res)
+		}
+	}
+	return o
+}
+
 func runPool[R any](ctx context.Context, repos []RepoInfo, workers int, process func(context.Context, RepoInfo) R) []R {
+	return runPoolWith(ctx, repos, workers, poolOptions[R]{}, process)
+}
+
+func runPoolWith[R any](ctx context.Context, repos []RepoInfo, workers int, opts poolOptions[R], process func(context.Context, RepoInfo) R) []R {
 	if workers > len(repos) {
 		workers = len(repos)
 	}
 	if workers < 1 {
 		workers = 1
 	}
+	if opts.expected != nil {
+		repos = longestFirst(repos, opts.expected)
+	}
+
+	// A first Ctrl-C stops dispatch only; ctx itself is cancelled by the
+	// second one, which kills whatever is still running.
+	in := interrupterFrom(ctx)
+	dispatchCtx, stopDispatch := context.WithCancel(ctx)
+	defer stopDispatch()
+	if in != nil {
+		defer context.AfterFunc(in.drain, stopDispatch)()
+	}
+
+	control := opts.control
+	if control != nil {
+		control.attach(repos)
+		defer control.detach()
+	}
 
-	repoCh := make(chan RepoInfo, len(repos))
+	repoCh := make(chan RepoInfo)
 	resCh := make(chan R, len(repos))
 
+	// Failures are counted by the worker before it takes another repo, so
+	// the limit holds even when results are collected later.
+	var failures atomic.Int32
 	var wg sync.WaitGroup
 	for range workers {
 		wg.Go(func() {
 			for r := range repoCh {
-				select {
-				case <-ctx.Done():
-					return
-				default:
+				if dispatchCtx.Err() != nil || in.draining() {
+					in.record(r.RelPath, repoNotStarted)
+					if control != nil {
+						control.finish(r.RelPath)
+					}
+					if opts.cancelled != nil {
+						resCh <- opts.cancelled(r)
+					}
+					continue
 				}
-				resCh <- process(ctx, r)
+				start := time.Now()
+				res := runRepo(ctx, r, opts, process)
+				if t, ok := any(&res).(interface{ setDuration(time.Duration) }); ok {
+					t.setDuration(time.Since(start))
+				}
+				if interruptedResult(ctx, res) {
+					in.record(r.RelPath, repoInterrupted)
+				} else {
+					in.record(r.RelPath, repoCompleted)
+				}
+				if opts.maxFailures > 0 && opts.failed != nil && opts.failed(res) {
+					if int(failures.Add(1)) >= opts.maxFailures {
+						stopDispatch()
+					}
+				}
+				resCh <- res
 			}
 		})
 	}
-	for _, r := range repos {
-		repoCh <- r
-	}
-	close(repoCh)
+
+	var undispatched []RepoInfo
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		defer close(repoCh)
+		if control == nil {
+			for i, r := range repos {
+				if dispatchCtx.Err() == nil {
+					select {
+					case repoCh <- r:
+						continue
+					case <-dispatchCtx.Done():
+					}
+				}
+				undispatched = repos[i:]
+				return
+			}
+			return
+		}
+
+		// With a control attached the queue stays open for requeues until
+		// nothing is queued or running.
+		queue := append([]RepoInfo{}, repos...)
+		for dispatchCtx.Err() == nil {
+			if len(queue) == 0 {
+				if control.detachIfIdle() {
+					return
+				}
+				select {
+				case r := <-control.requeues:
+					queue = append(queue, r)
+				case <-control.wake:
+				case <-dispatchCtx.Done():
+				}
+				continue
+			}
+			select {
+			case repoCh <- queue[0]:
+				control.dispatched()
+				queue = queue[1:]
+			case r := <-control.requeues:
+				queue = append(queue, r)
+			case <-dispatchCtx.Done():
+			}
+		}
+		control.detach()
+		for len(control.requeues) > 0 {
+			queue = append(queue, <-control.requeues)
+		}
+		undispatched = queue
+	}()
 	go func() { wg.Wait(); close(resCh) }()
 
+	// A requeued repo's latest result replaces its earlier one.
 	results := make([]R, 0, len(repos))
-	for res := range resCh {
+	index := make(map[string]int)
+	collect := func(res R) {
+		if opts.onResult != nil {
+			opts.onResult(res)
+		}
+		if rr, ok := any(res).(repoResult); ok && control != nil {
+			if i, seen := index[rr.repoPath()]; seen {
+				results[i] = res
+				return
+			}
+			index[rr.repoPath()] = len(results)
+		}
 		results = append(results, res)
 	}
+	for res := range resCh {
+		collect(res)
+	}
+
+	<-dispatched
+	for _, r := range undispatched {
+		in.record(r.RelPath, repoNotStarted)
+		if opts.cancelled != nil {
+			collect(opts.cancelled(r))
+		}
+	}
 	return results
 }
+
+// runRepo processes one repo under its own context when the pool has a
+// control, so the TUI can cancel it. A repo that fails after being cancelled
+// that way is reported as cancelled.
+func runRepo[R any](ctx context.Context, r RepoInfo, opts poolOptions[R], process func(context.Context, RepoInfo) R) R {
+	if opts.control == nil {
+		return process(ctx, r)
+	}
+	repoCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	opts.control.start(r.RelPath, cancel)
+	res := process(repoCtx, r)
+	if repoCtx.Err() != nil && ctx.Err() == nil && opts.cancelled != nil && opts.failed != nil && opts.failed(res) {
+		res = opts.cancelled(r)
+	}
+	opts.control.finish(r.RelPath)
+	return res
+}
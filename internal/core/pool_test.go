@@ -0,0 +1,174 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testRepos(n int) []RepoInfo {
+	repos := make([]RepoInfo, n)
+	for i := range repos {
+		repos[i] = RepoInfo{RelPath: fmt.Sprintf("repo%02d", i)}
+	}
+	return repos
+}
+
+func TestRunPoolMaxFailuresCancelsRest(t *testing.T) {
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	cfg.MaxFailures = 2
+	opts := newRunOptions[CommandResult](cfg, newResultWriter("", "command"), &ProgressState{quiet: true})
+
+	var started atomic.Int32
+	results := runPoolWith(context.Background(), testRepos(10), 1, opts, func(_ context.Context, r RepoInfo) CommandResult {
+		started.Add(1)
+		return CommandResult{RelPath: r.RelPath, Error: errors.New("auth failed")}
+	})
+
+	if len(results) != 10 {
+		t.Fatalf("expected a result for every repo, got %d", len(results))
+	}
+	failed, cancelled := 0, 0
+	for _, res := range results {
+		switch state, _ := res.outcome(); state {
+		case statusFailed:
+			failed++
+		case statusCancelled:
+			cancelled++
+		}
+	}
+	if started.Load() != 2 || failed != 2 || cancelled != 8 {
+		t.Errorf("expected 2 started/failed and 8 cancelled, got %d/%d/%d", started.Load(), failed, cancelled)
+	}
+}
+
+func TestRunPoolWithoutLimitRunsEverything(t *testing.T) {
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	opts := newRunOptions[SwitchResult](cfg, newResultWriter("", "switch"), &ProgressState{quiet: true})
+
+	results := runPoolWith(context.Background(), testRepos(20), 4, opts, func(_ context.Context, r RepoInfo) SwitchResult {
+		return SwitchResult{RelPath: r.RelPath, Error: "boom"}
+	})
+	for _, res := range results {
+		if res.Cancelled {
+			t.Fatalf("unexpected cancelled repo %s", res.RelPath)
+		}
+	}
+	if len(results) != 20 {
+		t.Errorf("expected 20 results, got %d", len(results))
+	}
+}
+
+func TestRunPoolCancelledContextReportsRepos(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	opts := newRunOptions[ResetResult](cfg, newResultWriter("", "reset"), &ProgressState{quiet: true})
+
+	results := runPoolWith(ctx, testRepos(5), 2, opts, func(_ context.Context, r RepoInfo) ResetResult {
+		t.Errorf("repo %s should not have started", r.RelPath)
+		return ResetResult{RelPath: r.RelPath, Success: true}
+	})
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+	for _, res := range results {
+		if !res.Cancelled {
+			t.Errorf("expected %s to be cancelled", res.RelPath)
+		}
+	}
+}
+
+func TestExecuteShellInReposFailFast(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := range 3 {
+		createGitRepo(t, filepath.Join(tmpDir, fmt.Sprintf("repo%d", i)))
+	}
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+	cfg.MaxFailures = 1
+
+	err := runQuiet(t, func() error { return executeShellInRepos(context.Background(), tmpDir, "exit 1", 1, cfg) })
+	if !errors.Is(err, errReposCancelled) {
+		t.Fatalf("expected cancelled error, got %v", err)
+	}
+	if code := ExitCode(err); code != ExitCancelled {
+		t.Errorf("expected exit code %d, got %d", ExitCancelled, code)
+	}
+	if code := ExitCode(errReposFailed); code != ExitFailed {
+		t.Errorf("expected exit code %d for failures, got %d", ExitFailed, code)
+	}
+}
+
+func TestRunPoolRequeueKeepsLatestAttempt(t *testing.T) {
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	control := newRunControl()
+	opts := newRunOptions[CommandResult](cfg, newResultWriter("", "command"), &ProgressState{quiet: true, control: control})
+
+	release := make(chan struct{})
+	next := opts.onResult
+	opts.onResult = func(res CommandResult) {
+		next(res)
+		if res.RelPath == "repo01" && res.Error != nil {
+			if !control.requeue("repo01") {
+				t.Error("expected repo01 to be requeued")
+			}
+			close(release)
+		}
+	}
+
+	var attempts atomic.Int32
+	results := runPoolWith(context.Background(), testRepos(2), 2, opts, func(_ context.Context, r RepoInfo) CommandResult {
+		if r.RelPath == "repo00" {
+			<-release
+			return CommandResult{RelPath: r.RelPath}
+		}
+		if attempts.Add(1) == 1 {
+			return CommandResult{RelPath: r.RelPath, Error: errors.New("flaky")}
+		}
+		return CommandResult{RelPath: r.RelPath}
+	})
+
+	if len(results) != 2 || attempts.Load() != 2 {
+		t.Fatalf("expected 2 results and 2 attempts, got %d and %d", len(results), attempts.Load())
+	}
+	for _, res := range results {
+		if res.Error != nil {
+			t.Errorf("expected the retried result for %s, got %v", res.RelPath, res.Error)
+		}
+	}
+	if control.requeue("repo01") {
+		t.Error("expected requeues to be refused once the pool is done")
+	}
+}
+
+func TestRunPoolCancelRunningRepo(t *testing.T) {
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	control := newRunControl()
+	opts := newRunOptions[CommandResult](cfg, newResultWriter("", "command"), &ProgressState{quiet: true, control: control})
+
+	go func() {
+		for !control.cancel("repo00") {
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	results := runPoolWith(context.Background(), testRepos(2), 2, opts, func(ctx context.Context, r RepoInfo) CommandResult {
+		if r.RelPath == "repo01" {
+			return CommandResult{RelPath: r.RelPath}
+		}
+		<-ctx.Done()
+		return CommandResult{RelPath: r.RelPath, Error: ctx.Err()}
+	})
+
+	states := make(map[string]string)
+	for _, res := range results {
+		states[res.RelPath], _ = res.outcome()
+	}
+	if states["repo00"] != statusCancelled || states["repo01"] != statusCompleted {
+		t.Errorf("expected only repo00 to be cancelled, got %v", states)
+	}
+}
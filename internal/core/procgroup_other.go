@@ -0,0 +1,20 @@
+//go:build !windows
+
+package core
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// isolateProcessGroup starts cmd in a new session, which also gives it its
+// own process group. With no controlling terminal, a password or passphrase
+// prompt fails straight away instead of stopping the command in the
+// background.
+func isolateProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
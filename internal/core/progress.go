@@ -2,7 +2,10 @@ package core
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -10,7 +13,9 @@ import (
 
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 const (
@@ -19,15 +24,23 @@ const (
 	statusCompleted  = "completed"
 	statusFailed     = "failed"
 	statusSkipped    = "skipped"
+	statusCancelled  = "cancelled"
+)
+
+const (
+	outputPaneLines = 10
+	logPaneLines    = 15
 )
 
 type statusMsg struct{ relPath, state, message string }
+type outputMsg struct{ line string }
 type doneMsg struct{}
 type tickMsg time.Time
 
 type repoStatus struct {
 	state   string
 	message string
+	started time.Time
 }
 
 type model struct {
@@ -42,6 +55,23 @@ type model struct {
 	startTime time.Time
 	done      bool
 	opName    string
+	output    []string
+	interrupt func() int
+	stopping  int
+	control   *runControl
+	expected  map[string]time.Duration
+	workers   int
+	selected  string
+	notice    string
+	// pendingRequeue is the repo whose requeue waits for a second r.
+	pendingRequeue string
+	logRepo        string
+	logLines       []string
+
+	filter       string
+	filterInput  textinput.Model
+	filtering    bool
+	statusFilter string
 }
 
 func newModel(repos []RepoInfo, opName string, pageSize int) model {
@@ -56,6 +86,8 @@ func newModel(repos []RepoInfo, opName string, pageSize int) model {
 	}
 
 	p := progress.New(progress.WithDefaultGradient())
+	filterInput := textinput.New()
+	filterInput.Prompt = "/"
 
 	return model{
 		statuses:  statuses,
@@ -67,6 +99,8 @@ func newModel(repos []RepoInfo, opName string, pageSize int) model {
 		width:     80,
 		startTime: time.Now(),
 		opName:    opName,
+
+		filterInput: filterInput,
 	}
 }
 
@@ -83,7 +117,21 @@ func (m model) Init() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case statusMsg:
-		m.statuses[msg.relPath] = repoStatus{state: msg.state, message: msg.message}
+		st := repoStatus{state: msg.state, message: msg.message}
+		if msg.state == statusProcessing {
+			st.started = time.Now()
+		}
+		m.statuses[msg.relPath] = st
+		if msg.relPath == m.logRepo {
+			m.loadLog()
+		}
+		return m, nil
+
+	case outputMsg:
+		m.output = append(m.output, msg.line)
+		if len(m.output) > outputPaneLines {
+			m.output = m.output[len(m.output)-outputPaneLines:]
+		}
 		return m, nil
 
 	case doneMsg:
@@ -91,17 +139,67 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 
 	case tea.KeyMsg:
+		if m.filtering && msg.String() != "ctrl+c" {
+			return m.updateFilter(msg)
+		}
+		if m.pendingRequeue != "" && msg.String() != "r" {
+			m.pendingRequeue, m.notice = "", ""
+		}
 		switch msg.String() {
-		case "up", "pgup":
-			if m.page > 0 {
+		case "up", "k":
+			m.moveSelection(-1)
+		case "down", "j":
+			m.moveSelection(1)
+		case "pgup", "left":
+			if m.page = m.currentPage(); m.page > 0 {
 				m.page--
+				m.selected = ""
 			}
-		case "down", "pgdown":
-			if m.page < m.totalPages()-1 {
+		case "pgdown", "right":
+			if m.page = m.currentPage(); m.page < m.totalPages()-1 {
 				m.page++
+				m.selected = ""
+			}
+		case "g", "home":
+			m.page = 0
+			m.selected = ""
+		case "G", "end":
+			m.page = m.totalPages() - 1
+			if page := m.sortedPage(); len(page) > 0 {
+				m.selected = page[len(page)-1]
+			}
+		case "/":
+			m.filtering = true
+			m.filterInput.SetValue(m.filter)
+			m.filterInput.CursorEnd()
+			return m, m.filterInput.Focus()
+		case "f":
+			m.cycleStatusFilter()
+		case "r":
+			m.requeueSelected()
+		case "x":
+			m.cancelSelected()
+		case "l":
+			if m.control == nil {
+				break
+			}
+			if sel := m.selection(); sel == m.logRepo {
+				m.logRepo, m.logLines = "", nil
+			} else {
+				m.logRepo = sel
+				m.loadLog()
+			}
+		case "esc":
+			if m.logRepo != "" {
+				m.logRepo, m.logLines = "", nil
+			} else {
+				m.filter, m.statusFilter = "", ""
 			}
 		case "ctrl+c":
-			return m, tea.Quit
+			if m.interrupt == nil {
+				return m, tea.Quit
+			}
+			m.stopping = m.interrupt()
 		}
 		return m, nil
 
@@ -121,6 +219,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 
 	case tickMsg:
+		if m.logRepo != "" {
+			m.loadLog()
+		}
 		return m, tickEvery()
 	}
 
@@ -138,30 +239,215 @@ func (m model) View() string {
 	if m.done {
 		fmt.Fprintf(&sb, "✓ %s - Done\n\n", m.opName)
 	} else {
-		fmt.Fprintf(&sb, "%s %s  %s\n\n", m.spinner.View(), m.opName, elapsed)
+		fmt.Fprintf(&sb, "%s %s  %s", m.spinner.View(), m.opName, elapsed)
+		if eta, ok := m.eta(); ok {
+			sb.WriteString("  " + StyleDim.Render(formatETA(eta)))
+		}
+		sb.WriteString("\n\n")
+	}
+	if m.stopping > 0 && !m.done {
+		sb.WriteString("  " + StyleSkipped.Render(interruptMessage(m.stopping)) + "\n\n")
 	}
 
-	completed, failed, processing, waiting, skipped := m.countStatuses()
-	pct := float64(completed+failed+skipped) / float64(m.total)
+	completed, failed, processing, waiting, skipped, cancelled := m.countStatuses()
+	finished := completed + failed + skipped + cancelled
+	pct := float64(finished) / float64(m.total)
 
 	sb.WriteString("  ")
 	sb.WriteString(m.progBar.ViewAs(pct))
-	fmt.Fprintf(&sb, "  %d/%d  ✅ %d  ❌ %d  ⏭️ %d  🔄 %d  ⏳ %d\n\n",
-		completed+failed+skipped, m.total, completed, failed, skipped, processing, waiting)
+	fmt.Fprintf(&sb, "  %d/%d  ✅ %d  ❌ %d  ⏭️ %d  🔄 %d  ⏳ %d",
+		finished, m.total, completed, failed, skipped, processing, waiting)
+	if cancelled > 0 {
+		fmt.Fprintf(&sb, "  🚫 %d", cancelled)
+	}
+	sb.WriteString("\n\n")
 
+	selected := m.selection()
 	for _, relPath := range m.sortedPage() {
-		sb.WriteString("  ")
+		if m.control != nil && relPath == selected {
+			sb.WriteString("› ")
+		} else {
+			sb.WriteString("  ")
+		}
 		sb.WriteString(m.formatRepoLine(relPath, m.statuses[relPath]))
 		sb.WriteString("\n")
 	}
 
 	if m.totalPages() > 1 {
-		fmt.Fprintf(&sb, "\n  Page %d/%d  ↑↓ PgUp/PgDn\n", m.page+1, m.totalPages())
+		fmt.Fprintf(&sb, "\n  Page %d/%d  PgUp/PgDn\n", m.currentPage()+1, m.totalPages())
+	}
+	if !m.done {
+		switch {
+		case m.filtering:
+			sb.WriteString("\n  " + m.filterInput.View() + "\n")
+		case m.filter != "" || m.statusFilter != "":
+			sb.WriteString("\n  " + StyleInfo.Render(m.filterSummary()) + "\n")
+		}
+		help := "↑↓ select  g/G top/bottom  / filter  f status"
+		if m.control != nil {
+			help += "  r retry  l log  x cancel"
+		}
+		sb.WriteString("\n  " + StyleDim.Render(help) + "\n")
+	}
+	if m.notice != "" && !m.done {
+		sb.WriteString("  " + StyleInfo.Render(m.notice) + "\n")
+	}
+
+	if len(m.output) > 0 {
+		sb.WriteString("\n" + StyleDim.Render("  ── output ──") + "\n")
+		paneLine := lipgloss.NewStyle().MaxWidth(max(m.width-2, 20))
+		for _, line := range m.output {
+			sb.WriteString("  ")
+			sb.WriteString(paneLine.Render(line))
+			sb.WriteString("\n")
+		}
+	}
+
+	if m.logRepo != "" && !m.done {
+		sb.WriteString("\n" + StyleDim.Render("  ── log: "+m.logRepo+" (l/esc to close) ──") + "\n")
+		paneLine := lipgloss.NewStyle().MaxWidth(max(m.width-2, 20))
+		for _, line := range m.logLines {
+			sb.WriteString("  ")
+			sb.WriteString(paneLine.Render(line))
+			sb.WriteString("\n")
+		}
 	}
 
 	return sb.String()
 }
 
+// selection is the highlighted repo, defaulting to the top of the page when
+// nothing on it is selected.
+func (m model) selection() string {
+	page := m.sortedPage()
+	for _, relPath := range page {
+		if relPath == m.selected {
+			return relPath
+		}
+	}
+	if len(page) == 0 {
+		return ""
+	}
+	return page[0]
+}
+
+func (m *model) moveSelection(delta int) {
+	page := m.sortedPage()
+	if len(page) == 0 {
+		return
+	}
+	i, sel := 0, m.selection()
+	for j, relPath := range page {
+		if relPath == sel {
+			i = j
+		}
+	}
+	i += delta
+	m.page = m.currentPage()
+	switch {
+	case i < 0 && m.page > 0:
+		m.page--
+		page = m.sortedPage()
+		i = len(page) - 1
+	case i >= len(page) && m.page < m.totalPages()-1:
+		m.page++
+		page = m.sortedPage()
+		i = 0
+	}
+	m.selected = page[max(min(i, len(page)-1), 0)]
+}
+
+func (m model) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.filtering = false
+		m.filterInput.Blur()
+		return m, nil
+	case tea.KeyEsc:
+		m.filtering = false
+		m.filterInput.Blur()
+		m.filter = ""
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	m.filter = strings.TrimSpace(m.filterInput.Value())
+	m.page, m.selected = 0, ""
+	return m, cmd
+}
+
+func (m *model) cycleStatusFilter() {
+	for i, f := range progressFilters {
+		if f == m.statusFilter {
+			m.statusFilter = progressFilters[(i+1)%len(progressFilters)]
+			break
+		}
+	}
+	m.page, m.selected = 0, ""
+}
+
+func (m model) filterSummary() string {
+	var parts []string
+	if m.filter != "" {
+		parts = append(parts, "filter: "+m.filter)
+	}
+	if m.statusFilter != "" {
+		parts = append(parts, "status: "+m.statusFilter)
+	}
+	return fmt.Sprintf("%s  (%d of %d repos, Esc to clear)", strings.Join(parts, "  "), len(m.visible()), m.total)
+}
+
+func (m *model) requeueSelected() {
+	relPath := m.selection()
+	if m.control == nil || relPath == "" {
+		return
+	}
+	if st := m.statuses[relPath].state; st == statusWaiting || st == statusProcessing {
+		m.notice = relPath + " has not finished yet"
+		return
+	}
+	if m.control.confirmRequeue && m.pendingRequeue != relPath {
+		m.pendingRequeue = relPath
+		m.notice = fmt.Sprintf("Press r again to re-run '%s' on %s; any other key keeps it", m.opName, relPath)
+		return
+	}
+	m.pendingRequeue = ""
+	if m.control.requeue(relPath) {
+		m.statuses[relPath] = repoStatus{state: statusWaiting}
+		m.notice = "Requeued " + relPath
+	} else {
+		m.notice = relPath + " can't be retried in this run"
+	}
+}
+
+func (m *model) cancelSelected() {
+	relPath := m.selection()
+	if m.control == nil || relPath == "" {
+		return
+	}
+	if m.control.cancel(relPath) {
+		m.notice = "Cancelling " + relPath
+		if m.control.cancelNote != "" {
+			m.notice += " (" + m.control.cancelNote + ")"
+		}
+	} else {
+		m.notice = relPath + " is not running"
+	}
+}
+
+func (m *model) loadLog() {
+	content, err := m.control.readLog(m.logRepo)
+	if err != nil {
+		m.logLines = []string{StyleDim.Render("(" + err.Error() + ")")}
+		return
+	}
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(lines) > logPaneLines {
+		lines = lines[len(lines)-logPaneLines:]
+	}
+	m.logLines = lines
+}
+
 func (m model) formatRepoLine(relPath string, st repoStatus) string {
 	switch st.state {
 	case statusFailed:
@@ -188,12 +474,14 @@ func (m model) formatRepoLine(relPath string, st repoStatus) string {
 			skipSuffix = "  " + StyleDim.Render(st.message)
 		}
 		return "⏭️ " + StyleSkipped.Render(relPath) + skipSuffix
+	case statusCancelled:
+		return "🚫 " + StyleDim.Render(relPath+"  cancelled")
 	default:
 		return "⏳ " + relPath
 	}
 }
 
-func (m model) countStatuses() (completed, failed, processing, waiting, skipped int) {
+func (m model) countStatuses() (completed, failed, processing, waiting, skipped, cancelled int) {
 	for _, st := range m.statuses {
 		switch st.state {
 		case statusCompleted:
@@ -206,59 +494,175 @@ func (m model) countStatuses() (completed, failed, processing, waiting, skipped
 			waiting++
 		case statusSkipped:
 			skipped++
+		case statusCancelled:
+			cancelled++
 		}
 	}
 	return
 }
 
+// eta estimates the time left from the durations of earlier runs.
+func (m model) eta() (time.Duration, bool) {
+	if m.expected == nil {
+		return 0, false
+	}
+	var running, waiting []time.Duration
+	for relPath, st := range m.statuses {
+		switch st.state {
+		case statusProcessing:
+			running = append(running, max(m.expected[relPath]-time.Since(st.started), 0))
+		case statusWaiting:
+			waiting = append(waiting, m.expected[relPath])
+		}
+	}
+	return estimateRemaining(m.workers, running, waiting)
+}
+
+// progressFilters is the cycle the f key steps through; "" shows every repo.
+var progressFilters = []string{"", statusFailed, statusProcessing, statusWaiting, statusCompleted, statusSkipped, statusCancelled}
+
+var statusPriority = map[string]int{
+	statusFailed:     0,
+	statusProcessing: 1,
+	statusWaiting:    2,
+	statusCancelled:  3,
+	statusSkipped:    4,
+	statusCompleted:  5,
+}
+
+// matchesRepoFilter matches a glob the way -i/-e do, or else a
+// case-insensitive substring.
+func matchesRepoFilter(filter, relPath string) bool {
+	if filter == "" {
+		return true
+	}
+	slashPath := filepath.ToSlash(relPath)
+	if hasGlobMeta(filter) {
+		return matchesGlob(filter, slashPath)
+	}
+	return strings.Contains(strings.ToLower(slashPath), strings.ToLower(filter))
+}
+
+// visible lists the repos that pass the filters, sorted by status across all
+// pages so failures come first.
+func (m model) visible() []string {
+	items := make([]string, 0, len(m.order))
+	for _, relPath := range m.order {
+		if m.statusFilter != "" && m.statuses[relPath].state != m.statusFilter {
+			continue
+		}
+		if !matchesRepoFilter(m.filter, relPath) {
+			continue
+		}
+		items = append(items, relPath)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return statusPriority[m.statuses[items[i]].state] < statusPriority[m.statuses[items[j]].state]
+	})
+	return items
+}
+
 func (m model) totalPages() int {
-	if m.pageSize <= 0 {
+	n := len(m.visible())
+	if m.pageSize <= 0 || n == 0 {
 		return 1
 	}
-	return (len(m.order) + m.pageSize - 1) / m.pageSize
+	return (n + m.pageSize - 1) / m.pageSize
+}
+
+// currentPage clamps m.page, which can point past the end once a filter or
+// a status change shrinks the list.
+func (m model) currentPage() int {
+	return min(m.page, m.totalPages()-1)
 }
 
 func (m model) sortedPage() []string {
-	start := m.page * m.pageSize
-	end := min(start+m.pageSize, len(m.order))
-	pageItems := m.order[start:end]
-
-	priority := map[string]int{
-		statusFailed:     0,
-		statusProcessing: 1,
-		statusWaiting:    2,
-		statusSkipped:    3,
-		statusCompleted:  4,
-	}
-
-	sorted := make([]string, len(pageItems))
-	copy(sorted, pageItems)
-	for i := 1; i < len(sorted); i++ {
-		for j := i; j > 0 && priority[m.statuses[sorted[j]].state] < priority[m.statuses[sorted[j-1]].state]; j-- {
-			sorted[j], sorted[j-1] = sorted[j-1], sorted[j]
-		}
+	items := m.visible()
+	if m.pageSize <= 0 {
+		return items
 	}
-	return sorted
+	start := m.currentPage() * m.pageSize
+	end := min(start+m.pageSize, len(items))
+	return items[start:end]
 }
 
 type ProgressState struct {
 	program      *tea.Program
+	plain        *plainProgress
+	control      *runControl
+	expected     map[string]time.Duration
 	supportsANSI bool
+	quiet        bool
 	stopped      atomic.Bool
 	wg           sync.WaitGroup
 	stopOnce     sync.Once
 }
 
 func NewProgressState(repos []RepoInfo, operationName string, pageSize int) *ProgressState {
-	ansi := supportsANSI()
-	ps := &ProgressState{supportsANSI: ansi}
-	if ansi {
+	return newProgressState(repos, operationName, pageSize, "", nil, os.Stdout, 0, nil)
+}
+
+// newProgressState picks the TUI on a terminal and plain lines elsewhere
+// unless a mode is given. The TUI reads Ctrl-C as a key rather than a
+// signal, so it forwards it to the run's interrupter when there is one.
+// expected, when known, drives the ETA and the order repos are run in; the
+// ETA assumes the run's workers are all busy while repos are waiting.
+func newProgressState(repos []RepoInfo, operationName string, pageSize int, mode string, in *interrupter, w io.Writer, workers int, expected map[string]time.Duration) *ProgressState {
+	if mode == "" {
+		mode = progressPlain
+		if supportsANSI() {
+			mode = progressTUI
+		}
+	}
+
+	ps := &ProgressState{expected: expected}
+	switch mode {
+	case progressTUI:
+		ps.supportsANSI = true
 		m := newModel(repos, operationName, pageSize)
+		if in != nil {
+			m.interrupt = in.interrupt
+		}
+		ps.control = newRunControl()
+		m.control = ps.control
+		m.expected = expected
+		m.workers = workers
 		ps.program = tea.NewProgram(m)
+	case progressPlain, progressQuiet:
+		ps.plain = newPlainProgress(w, repos, mode == progressPlain)
+		ps.plain.expected = expected
+		ps.plain.workers = workers
+	default:
+		ps.quiet = true
 	}
 	return ps
 }
 
+// newProgress shows no progress with -o unless --progress asks for it; it
+// then goes to stderr with the other informational output.
+func (cfg *Config) newProgress(repos []RepoInfo, operationName string, workers int, expected map[string]time.Duration) *ProgressState {
+	mode := cfg.Progress
+	if mode == "" && cfg.Output != "" {
+		mode = progressNone
+	}
+	return newProgressState(repos, operationName, cfg.PageSize, mode, cfg.interrupts, cfg.infoWriter(), workers, expected)
+}
+
+// confirmRequeues makes the TUI ask before re-running a repo, for
+// operations that discard work.
+func (ps *ProgressState) confirmRequeues() {
+	if ps.control != nil {
+		ps.control.confirmRequeue = true
+	}
+}
+
+// noteOnCancel adds what a cancel can't stop to the TUI's notice.
+func (ps *ProgressState) noteOnCancel(note string) {
+	if ps.control != nil {
+		ps.control.cancelNote = note
+	}
+}
+
 func supportsANSI() bool {
 	if os.Getenv("NO_COLOR") != "" {
 		return false
@@ -274,6 +678,10 @@ func supportsANSI() bool {
 }
 
 func (ps *ProgressState) StartInput() {
+	if ps.plain != nil {
+		ps.plain.start()
+		return
+	}
 	if !ps.supportsANSI || ps.program == nil {
 		return
 	}
@@ -287,6 +695,9 @@ func (ps *ProgressState) StartInput() {
 func (ps *ProgressState) StopInput() {
 	ps.stopOnce.Do(func() {
 		ps.stopped.Store(true)
+		if ps.plain != nil {
+			ps.plain.finish()
+		}
 		if ps.program != nil {
 			ps.program.Send(doneMsg{})
 		}
@@ -295,14 +706,26 @@ func (ps *ProgressState) StopInput() {
 }
 
 func (ps *ProgressState) UpdateStatus(relPath, status, errorMsg string) {
-	if ps.stopped.Load() {
+	if ps.quiet || ps.stopped.Load() {
 		return
 	}
 	if ps.program != nil {
 		ps.program.Send(statusMsg{relPath: relPath, state: status, message: errorMsg})
 		return
 	}
-	fmt.Printf("%s: %s\n", relPath, status)
+	if ps.plain != nil {
+		ps.plain.update(relPath, status, errorMsg)
+	}
+}
+
+// sendOutput routes a streamed line into the TUI's output pane; it reports
+// false when there is no TUI to draw it, so the caller prints it instead.
+func (ps *ProgressState) sendOutput(line string) bool {
+	if ps.quiet || ps.program == nil || ps.stopped.Load() {
+		return false
+	}
+	ps.program.Send(outputMsg{line: line})
+	return true
 }
 
 func (ps *ProgressState) start() func() {
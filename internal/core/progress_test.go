@@ -0,0 +1,101 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func sendKeys(m model, keys ...string) model {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		updated, _ := m.Update(msg)
+		m = updated.(model)
+	}
+	return m
+}
+
+func TestProgressSortsFailuresOntoFirstPage(t *testing.T) {
+	m := newModel(testRepos(30), "test", 10)
+	for _, r := range testRepos(30) {
+		m.statuses[r.RelPath] = repoStatus{state: statusCompleted}
+	}
+	m.statuses["repo27"] = repoStatus{state: statusFailed}
+	m.statuses["repo15"] = repoStatus{state: statusProcessing}
+
+	page := m.sortedPage()
+	if len(page) != 10 || page[0] != "repo27" || page[1] != "repo15" || page[2] != "repo00" {
+		t.Errorf("expected failures then running repos first, got %v", page)
+	}
+
+	m = sendKeys(m, "G")
+	if m.currentPage() != 2 || m.selection() != "repo29" {
+		t.Errorf("expected G to select the last repo, got page %d, %s", m.currentPage(), m.selection())
+	}
+	m = sendKeys(m, "g")
+	if m.currentPage() != 0 || m.selection() != "repo27" {
+		t.Errorf("expected g to select the first repo, got page %d, %s", m.currentPage(), m.selection())
+	}
+}
+
+func TestProgressFilters(t *testing.T) {
+	repos := []RepoInfo{{RelPath: "services/api"}, {RelPath: "services/web"}, {RelPath: "tools/cli"}, {RelPath: "tools/api-gen"}}
+	m := newModel(repos, "test", 20)
+	m.statuses["services/web"] = repoStatus{state: statusFailed}
+
+	m = sendKeys(m, "/", "A", "P", "I", "enter")
+	if got := strings.Join(m.sortedPage(), ","); got != "services/api,tools/api-gen" {
+		t.Errorf("expected a case-insensitive substring filter, got %s", got)
+	}
+	if !strings.Contains(m.View(), "2 of 4 repos") {
+		t.Errorf("expected the filter summary in the view:\n%s", m.View())
+	}
+
+	m = sendKeys(m, "/", "esc", "/", "t", "o", "o", "l", "s", "/", "*", "enter")
+	if got := strings.Join(m.sortedPage(), ","); got != "tools/cli,tools/api-gen" {
+		t.Errorf("expected a glob filter, got %s", got)
+	}
+
+	m = sendKeys(m, "esc", "f")
+	if m.statusFilter != statusFailed || strings.Join(m.sortedPage(), ",") != "services/web" {
+		t.Errorf("expected f to show failed repos only, got %q %v", m.statusFilter, m.sortedPage())
+	}
+	m = sendKeys(m, "f")
+	if m.statusFilter != statusProcessing || len(m.sortedPage()) != 0 {
+		t.Errorf("expected the next status filter to be processing, got %q %v", m.statusFilter, m.sortedPage())
+	}
+}
+
+func TestProgressConfirmsDestructiveRequeue(t *testing.T) {
+	repos := testRepos(2)
+	m := newModel(repos, "git reset --hard origin/main", 10)
+	m.control = newRunControl()
+	m.control.attach(repos)
+	m.control.confirmRequeue = true
+	for _, r := range repos {
+		m.statuses[r.RelPath] = repoStatus{state: statusFailed}
+		m.control.finished[r.RelPath] = true
+	}
+
+	m = sendKeys(m, "r")
+	if len(m.control.requeues) != 0 || !strings.Contains(m.notice, "Press r again") {
+		t.Fatalf("expected r to ask first, got notice %q", m.notice)
+	}
+	m = sendKeys(m, "j", "k")
+	if m.pendingRequeue != "" || len(m.control.requeues) != 0 {
+		t.Fatal("expected another key to drop the pending requeue")
+	}
+	m = sendKeys(m, "r", "r")
+	if len(m.control.requeues) != 1 || m.statuses["repo00"].state != statusWaiting {
+		t.Errorf("expected r twice to requeue repo00, got notice %q", m.notice)
+	}
+}
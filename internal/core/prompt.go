@@ -3,7 +3,9 @@ package core
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 )
 
@@ -33,11 +35,19 @@ type logEntry struct {
 	relPath    string
 	failed     bool
 	skipped    bool
+	cancelled  bool
 	skipReason string
 	label      string
 }
 
 func displayLogEntries(logManager *LogManager, entries []logEntry) {
+	if supportsANSI() {
+		if err := runLogViewer(logManager, entries); err == nil {
+			fmt.Printf("Logs are stored in: %s\n", StyleDim.Render(logManager.GetLogDir()))
+			return
+		}
+	}
+
 	fmt.Println("\n" + StyleBold.Render("=== Detailed Logs ==="))
 	fmt.Println()
 
@@ -71,10 +81,23 @@ func displayLogEntries(logManager *LogManager, entries []logEntry) {
 		}
 	}
 
+	var cancelled []string
+	for _, e := range entries {
+		if e.cancelled {
+			cancelled = append(cancelled, e.relPath)
+		}
+	}
+	if len(cancelled) > 0 {
+		fmt.Println("\n" + StyleDim.Render("--- Cancelled Repositories (never started) ---"))
+		for _, relPath := range cancelled {
+			fmt.Printf("  [CANCELLED] %s\n", relPath)
+		}
+	}
+
 	fmt.Println("\n" + StyleSuccess.Render("--- Successful Repositories ---"))
 	hasSuccess := false
 	for _, e := range entries {
-		if !e.failed && !e.skipped {
+		if !e.failed && !e.skipped && !e.cancelled {
 			hasSuccess = true
 			label := e.label
 			if label == "" {
@@ -88,7 +111,7 @@ func displayLogEntries(logManager *LogManager, entries []logEntry) {
 	}
 
 	fmt.Println("\n" + StyleBold.Render("--- Log Location ---"))
-	fmt.Printf("Logs are stored in: %s\n", StyleDim.Render(logManager.GetTempDir()))
+	fmt.Printf("Logs are stored in: %s\n", StyleDim.Render(logManager.GetLogDir()))
 	fmt.Printf("Total log files: %s\n", StyleDim.Render(fmt.Sprintf("%d", len(allLogs))))
 }
 
@@ -96,8 +119,9 @@ func DisplayLogs(logManager *LogManager, results []CommandResult) {
 	entries := make([]logEntry, len(results))
 	for i, res := range results {
 		entries[i] = logEntry{
-			relPath: res.RelPath,
-			failed:  res.Error != nil,
+			relPath:   res.RelPath,
+			failed:    res.Error != nil,
+			cancelled: res.Cancelled,
 		}
 	}
 	displayLogEntries(logManager, entries)
@@ -108,8 +132,9 @@ func DisplayResetLogs(logManager *LogManager, results []ResetResult) {
 	for i, res := range results {
 		e := logEntry{
 			relPath:    res.RelPath,
-			failed:     !res.Success && !res.Skipped,
+			failed:     !res.Success && !res.Skipped && !res.Cancelled,
 			skipped:    res.Skipped,
+			cancelled:  res.Cancelled,
 			skipReason: res.SkipReason,
 		}
 		if res.Success && res.Warning != "" {
@@ -125,14 +150,92 @@ func DisplaySwitchLogs(logManager *LogManager, results []SwitchResult) {
 	for i, res := range results {
 		entries[i] = logEntry{
 			relPath:    res.RelPath,
-			failed:     !res.Success && !res.Skipped,
+			failed:     !res.Success && !res.Skipped && !res.Cancelled,
 			skipped:    res.Skipped,
+			cancelled:  res.Cancelled,
 			skipReason: res.Error,
 		}
 	}
 	displayLogEntries(logManager, entries)
 }
 
+type outputGroup struct {
+	output string
+	repos  []string
+	failed int
+}
+
+func normalizeOutput(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// groupOutputs clusters repos by normalized output, largest cluster first.
+func groupOutputs(logManager *LogManager, results []CommandResult) []*outputGroup {
+	byOutput := make(map[string]*outputGroup)
+	for _, res := range results {
+		if res.Cancelled {
+			continue
+		}
+		content, err := logManager.ReadLog(res.RelPath)
+		if err != nil {
+			content = res.Output
+		}
+		key := normalizeOutput(content)
+		g, ok := byOutput[key]
+		if !ok {
+			g = &outputGroup{output: key}
+			byOutput[key] = g
+		}
+		g.repos = append(g.repos, res.RelPath)
+		if res.Error != nil {
+			g.failed++
+		}
+	}
+
+	groups := make([]*outputGroup, 0, len(byOutput))
+	for _, g := range byOutput {
+		sort.Strings(g.repos)
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].repos) != len(groups[j].repos) {
+			return len(groups[i].repos) > len(groups[j].repos)
+		}
+		return groups[i].repos[0] < groups[j].repos[0]
+	})
+	return groups
+}
+
+func DisplayGroupedLogs(logManager *LogManager, results []CommandResult) {
+	groups := groupOutputs(logManager, results)
+	fmt.Println("\n" + StyleBold.Render(fmt.Sprintf("=== Grouped Output (%d distinct) ===", len(groups))))
+
+	for _, g := range groups {
+		label := fmt.Sprintf("%d repos", len(g.repos))
+		if len(g.repos) == 1 {
+			label = "1 repo"
+		}
+		header := StyleSuccess.Render("[" + label + "]")
+		if g.failed > 0 {
+			header = StyleFailed.Render(fmt.Sprintf("[%s, %d failed]", label, g.failed))
+		}
+		fmt.Printf("\n%s %s\n", header, strings.Join(g.repos, ", "))
+		fmt.Println("---")
+		if g.output == "" {
+			fmt.Println("(no output)")
+		} else {
+			fmt.Println(g.output)
+		}
+		fmt.Println("---")
+	}
+
+	fmt.Printf("\nLogs are stored in: %s\n", StyleDim.Render(logManager.GetLogDir()))
+}
+
 func displayRepoLog(logManager *LogManager, relPath, status string) {
 	content, err := logManager.ReadLog(relPath)
 	if err != nil {
@@ -151,17 +254,30 @@ func displayRepoLog(logManager *LogManager, relPath, status string) {
 	fmt.Println("---")
 }
 
-func PromptConfirmDestructive(opDesc string, repoCount int, dirtyRepos []repoPreflightInfo) bool {
+// checkConfirmable reports why the confirmation for a destructive operation
+// can't be asked: nobody answers it under -o or when stdin isn't a terminal.
+func (cfg *Config) checkConfirmable() error {
+	if cfg.Output != "" {
+		return fmt.Errorf("-o %s can't show the confirmation for destructive operations; add --yes to proceed without it", cfg.Output)
+	}
+	fileInfo, statErr := os.Stdin.Stat()
+	if statErr != nil || (fileInfo.Mode()&os.ModeCharDevice) == 0 {
+		return fmt.Errorf("stdin is not a terminal; destructive operations require interactive confirmation — add --yes to skip it, or use -rs for non-interactive use")
+	}
+	return nil
+}
+
+func PromptConfirmDestructive(w io.Writer, opDesc string, repoCount int, dirtyRepos []repoPreflightInfo) bool {
 	if len(dirtyRepos) > 0 {
-		fmt.Printf("\n%s The following %d repos have changes that will be DISCARDED:\n", StyleFailed.Render("WARNING:"), len(dirtyRepos))
+		_, _ = fmt.Fprintf(w, "\n%s The following %d repos have changes that will be DISCARDED:\n", StyleFailed.Render("WARNING:"), len(dirtyRepos))
 		for _, r := range dirtyRepos {
-			fmt.Printf("  - %s  (%s)\n", StyleFailed.Render(r.RelPath), StyleDim.Render(r.DirtyStatus))
+			_, _ = fmt.Fprintf(w, "  - %s  (%s)\n", StyleFailed.Render(r.RelPath), StyleDim.Render(r.DirtyStatus))
 		}
-		fmt.Println()
+		_, _ = fmt.Fprintln(w)
 	}
 
-	fmt.Printf("This will run '%s' on %d repos.\n", opDesc, repoCount)
-	fmt.Print("Proceed? (y/N): ")
+	_, _ = fmt.Fprintf(w, "This will run '%s' on %d repos.\n", opDesc, repoCount)
+	_, _ = fmt.Fprint(w, "Proceed? (y/N): ")
 
 	reader := bufio.NewReader(os.Stdin)
 	input, err := reader.ReadString('\n')
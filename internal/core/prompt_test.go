@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGroupOutputs(t *testing.T) {
+	lm, err := NewLogManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lm.Cleanup() }()
+
+	logs := map[string]string{
+		"a": "v20.1.0\n",
+		"b": "v20.1.0  \r\n",
+		"c": "\nv20.1.0\n\n",
+		"d": "v18.0.0\n",
+	}
+	var results []CommandResult
+	for repo, content := range logs {
+		f, err := lm.CreateLogFile(repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = f.WriteString(content)
+		_ = f.Close()
+		results = append(results, CommandResult{RelPath: repo})
+	}
+	results = append(results, CommandResult{RelPath: "e", Output: "boom", Error: errors.New("exit status 1")})
+
+	groups := groupOutputs(lm, results)
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups))
+	}
+	if groups[0].output != "v20.1.0" || strings.Join(groups[0].repos, ",") != "a,b,c" {
+		t.Errorf("unexpected largest group: %+v", groups[0])
+	}
+	if groups[1].output != "v18.0.0" || groups[2].output != "boom" || groups[2].failed != 1 {
+		t.Errorf("unexpected outlier groups: %+v %+v", groups[1], groups[2])
+	}
+}
+
+func TestExecuteShellInReposGroupOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"repo1", "repo2", "repo3"} {
+		createGitRepo(t, filepath.Join(tmpDir, name))
+	}
+	runCmd(t, filepath.Join(tmpDir, "repo3"), "git", "switch", "-c", "dev")
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.GroupOutput = true
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	runErr := executeShellInRepos(context.Background(), tmpDir, "git branch --show-current", 2, cfg)
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+	outBytes, _ := io.ReadAll(r)
+	out := string(outBytes)
+
+	if runErr != nil {
+		t.Fatalf("unexpected error: %v", runErr)
+	}
+	if !strings.Contains(out, "[2 repos] repo1, repo2") || !strings.Contains(out, "[1 repo] repo3") {
+		t.Errorf("expected grouped output, got:\n%s", out)
+	}
+	if strings.Index(out, "repo1, repo2") > strings.Index(out, "[1 repo] repo3") {
+		t.Error("expected the largest group first")
+	}
+}
@@ -65,14 +65,15 @@ const (
 )
 
 func discoverRepos(root string, workers int, cfg *Config, worktreeCmd bool) ([]RepoInfo, int) {
-	fmt.Printf("Discovering repos in %s...\n", root)
-	allRepos, err := findGitRepos(root, cfg)
+	info := cfg.infoWriter()
+	_, _ = fmt.Fprintf(info, "Discovering repos in %s...\n", root)
+	allRepos, err := cfg.findRepos(root)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Error:", err)
 		return nil, 0
 	}
 	if len(allRepos) == 0 {
-		fmt.Println("No repos found")
+		_, _ = fmt.Fprintln(info, "No repos found")
 		return nil, 0
 	}
 	repos := cfg.filterReposForExecution(allRepos)
@@ -82,22 +83,36 @@ func discoverRepos(root string, workers int, cfg *Config, worktreeCmd bool) ([]R
 		repos = cfg.filterWorktrees(repos)
 	}
 	if len(repos) == 0 {
-		fmt.Println("No repos match the specified include/exclude criteria")
+		_, _ = fmt.Fprintln(info, "No repos match the specified include/exclude criteria")
 		return nil, 0
 	}
+	if cfg.fromRun != nil {
+		repos = cfg.filterReposFromRun(repos)
+		if len(repos) == 0 {
+			_, _ = fmt.Fprintf(info, "No repos match --from-last %s (run %s)\n", cfg.fromRun.spec, cfg.fromRun.runID)
+			return nil, 0
+		}
+	}
 	repos = cfg.filterReposByBranch(repos, workers)
 	if len(repos) == 0 {
-		fmt.Println("No repos match the specified branch criteria")
+		_, _ = fmt.Fprintln(info, "No repos match the specified branch criteria")
 		return nil, 0
 	}
 	return repos, len(allRepos)
 }
 
+func (cfg *Config) findRepos(root string) ([]RepoInfo, error) {
+	if cfg.manifest != nil {
+		return cfg.manifest.checkedOutRepos(root, cfg.infoWriter()), nil
+	}
+	return findGitRepos(root, cfg)
+}
+
 func findGitRepos(root string, cfg *Config) ([]RepoInfo, error) {
 	scanner := &repoScanner{
 		cfg:        cfg,
 		visited:    make(map[string]bool),
-		output:     os.Stdout,
+		output:     cfg.infoWriter(),
 		lastUpdate: time.Now(),
 	}
 
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var fromLastOutcomes = map[string]string{
+	"failed":    statusFailed,
+	"skipped":   statusSkipped,
+	"succeeded": statusCompleted,
+}
+
+// runSelection restricts discovery to the repos that had the given outcomes
+// in a recorded run.
+type runSelection struct {
+	spec  string
+	runID string
+	paths map[string]struct{}
+}
+
+// loadRunSelection parses "<outcome>[,<outcome>...][@<run-id>]"; without a
+// run ID the newest recorded run is used.
+func loadRunSelection(spec string) (*runSelection, error) {
+	outcomes, id, _ := strings.Cut(spec, "@")
+	want := make(map[string]bool)
+	for _, o := range strings.Split(outcomes, ",") {
+		o = strings.TrimSpace(o)
+		status, ok := fromLastOutcomes[o]
+		if !ok {
+			return nil, fmt.Errorf("invalid --from-last outcome %q (use failed, skipped or succeeded)", o)
+		}
+		want[status] = true
+	}
+
+	id, err := resolveRunID(id)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := readRunRecord(id)
+	if err != nil {
+		return nil, err
+	}
+
+	sel := &runSelection{spec: spec, runID: id, paths: make(map[string]struct{})}
+	for _, r := range rec.Repos {
+		if want[r.Status] {
+			sel.paths[filepath.Join(rec.Root, filepath.FromSlash(r.RelPath))] = struct{}{}
+		}
+	}
+	return sel, nil
+}
+
+func (cfg *Config) filterReposFromRun(repos []RepoInfo) []RepoInfo {
+	if cfg.fromRun == nil {
+		return repos
+	}
+	filtered := make([]RepoInfo, 0, len(cfg.fromRun.paths))
+	for _, r := range repos {
+		if _, ok := cfg.fromRun.paths[filepath.Clean(r.Path)]; ok {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// stripRunSelectors drops --retry-failed and --from-last from a recorded
+// command line so it can be replayed with a new selection.
+func stripRunSelectors(args []string) []string {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(out, args[i:]...)
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") {
+			out = append(out, arg)
+			continue
+		}
+		switch name {
+		case "retry-failed":
+			continue
+		case "from-last":
+			if !hasValue && i+1 < len(args) {
+				i++
+			}
+			continue
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+// retryLastRun replays the newest recorded run's command line on the repos
+// that failed in it. Flags given alongside --retry-failed are added after
+// the recorded ones, so they win.
+func retryLastRun(ctx context.Context, args []string) error {
+	sel, err := loadRunSelection("failed")
+	if err != nil {
+		return err
+	}
+	rec, err := readRunRecord(sel.runID)
+	if err != nil {
+		return err
+	}
+	if len(rec.Args) == 0 {
+		return fmt.Errorf("run %s did not record its command line", sel.runID)
+	}
+	if len(sel.paths) == 0 {
+		fmt.Fprintf(os.Stderr, "No failed repos in run %s; nothing to retry.\n", sel.runID)
+		return nil
+	}
+
+	replay := stripRunSelectors(rec.Args)
+	split := len(replay)
+	for i, arg := range replay {
+		if arg == "--" {
+			split = i
+			break
+		}
+	}
+	retryArgs := append([]string{}, replay[:split]...)
+	retryArgs = append(retryArgs, stripRunSelectors(args)...)
+	retryArgs = append(retryArgs, "--from-last", "failed@"+sel.runID)
+	retryArgs = append(retryArgs, replay[split:]...)
+
+	fmt.Fprintln(os.Stderr, StyleInfo.Render(fmt.Sprintf("Retrying %d failed repos from run %s: gb %s", len(sel.paths), sel.runID, quoteCommandLine(retryArgs))))
+	return Run(ctx, retryArgs)
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripRunSelectors(t *testing.T) {
+	got := stripRunSelectors([]string{"--retry-failed", "-w", "5", "--from-last", "failed", "--from-last=skipped", "-o", "json", "--", "log", "--from-last"})
+	want := []string{"-w", "5", "-o", "json", "--", "log", "--from-last"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRetryFailedReplaysLastRun(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	for _, name := range []string{"ok", "bad1", "bad2"} {
+		createGitRepo(t, filepath.Join(tmpDir, name))
+	}
+	oldDir, _ := os.Getwd()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(oldDir) }()
+
+	first := runQuiet(t, func() error {
+		return Run(context.Background(), []string{"-o", "json", "-sh", `case $GB_NAME in bad*) exit 1;; esac`})
+	})
+	if first == nil {
+		t.Fatal("expected the first run to fail")
+	}
+
+	retry := runQuiet(t, func() error { return Run(context.Background(), []string{"--retry-failed"}) })
+	if retry == nil {
+		t.Fatal("expected the retry to fail again")
+	}
+	id, err := resolveRunID("last")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := readRunRecord(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, r := range rec.Repos {
+		paths = append(paths, r.RelPath)
+	}
+	if strings.Join(paths, ",") != "bad1,bad2" {
+		t.Errorf("expected only the failed repos to rerun, got %v", paths)
+	}
+	if !strings.Contains(strings.Join(rec.Args, " "), "--from-last failed@") {
+		t.Errorf("expected the retry to record its selector, got %q", rec.Args)
+	}
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	if cfg.fromRun, err = loadRunSelection("succeeded"); err != nil {
+		t.Fatal(err)
+	}
+	var repos []RepoInfo
+	_ = runQuiet(t, func() error { repos, _ = discoverRepos(tmpDir, 2, cfg, false); return nil })
+	if repos != nil {
+		t.Errorf("expected no repos to have succeeded in the retry, got %v", repos)
+	}
+}
@@ -11,12 +11,14 @@ import (
 )
 
 type ResetResult struct {
-	RelPath    string
-	Success    bool
-	Skipped    bool
-	SkipReason string
-	Error      string
-	Warning    string
+	RelPath    string `json:"relPath"`
+	Success    bool   `json:"success"`
+	Skipped    bool   `json:"skipped"`
+	SkipReason string `json:"skipReason"`
+	Cancelled  bool   `json:"cancelled"`
+	Error      string `json:"error"`
+	Warning    string `json:"warning"`
+	repoTiming
 }
 
 type repoPreflightInfo struct {
@@ -26,42 +28,70 @@ type repoPreflightInfo struct {
 }
 
 func syncBranch(ctx context.Context, root, branch, mode string, workers int, cfg *Config) error {
-	remote := cfg.Remote
+	remote, displayBranch := cfg.Remote, branch
+	if branch == "" {
+		if cfg.manifest == nil {
+			return fmt.Errorf("branch required (or use --manifest to reset each repo to its manifest revision)")
+		}
+		remote, displayBranch = "<remote>", "<revision>"
+	}
+	out := newResultWriter(cfg.Output, "reset")
 	repos, total := discoverRepos(root, workers, cfg, false)
 	if repos == nil {
-		return nil
+		return out.finish(false)
+	}
+	info := cfg.infoWriter()
+
+	if cfg.DryRun {
+		return planRepos(ctx, repos, workers, cfg, operationDescription(mode, displayBranch, remote), func(ctx context.Context, r RepoInfo, plan *RepoPlan) repoResult {
+			return cfg.resetOne(ctx, r, branch, mode, nil, plan)
+		})
 	}
 
-	if mode == "hard" || mode == "rebase" {
-		fileInfo, statErr := os.Stdin.Stat()
-		if statErr != nil || (fileInfo.Mode()&os.ModeCharDevice) == 0 {
-			return fmt.Errorf("stdin is not a terminal; destructive operations require interactive confirmation — use -rs for non-interactive use")
+	if (mode == "hard" || mode == "rebase") && !cfg.Yes {
+		if err := cfg.checkConfirmable(); err != nil {
+			return err
 		}
 
 		dirtyRepos := preflightScan(ctx, repos, workers)
-		if !PromptConfirmDestructive(operationDescription(mode, branch, remote), len(repos), dirtyRepos) {
-			fmt.Println("Aborted.")
+		if !PromptConfirmDestructive(info, operationDescription(mode, displayBranch, remote), len(repos), dirtyRepos) {
+			_, _ = fmt.Fprintln(info, "Aborted.")
 			return nil
 		}
 	}
 
-	opDesc := operationDescription(mode, branch, remote)
-	fmt.Println(StyleInfo.Render(fmt.Sprintf("Found %d repos (filtered from %d discovered), running '%s' with %d workers...",
+	opDesc := operationDescription(mode, displayBranch, remote)
+	fmt.Fprintln(info, StyleInfo.Render(fmt.Sprintf("Found %d repos (filtered from %d discovered), running '%s' with %d workers...",
 		len(repos), total, opDesc, min(workers, len(repos)))))
 
-	logManager, err := NewLogManager()
+	logManager, err := cfg.newLogManager(root, opDesc)
 	if err != nil {
 		return fmt.Errorf("log manager: %w", err)
 	}
 
-	progress := NewProgressState(repos, opDesc, cfg.PageSize)
+	backup := newBackupSession(logManager.RunID(), root, mode, opDesc)
+	progress := cfg.newProgress(repos, opDesc, workers, logManager.expectedDurations(repos))
+	switch mode {
+	case "hard":
+		progress.confirmRequeues()
+	case "rebase":
+		progress.confirmRequeues()
+		progress.noteOnCancel("a rebase that has started is left to finish")
+	}
 	stop := progress.start()
 
-	results := runPool(ctx, repos, workers, func(_ context.Context, r RepoInfo) ResetResult {
+	results := runPoolWith(ctx, repos, workers, newRunOptions[ResetResult](cfg, out, progress).withLogs(logManager), func(ctx context.Context, r RepoInfo) ResetResult {
 		progress.UpdateStatus(r.RelPath, statusProcessing, "")
 
 		logFile, _ := logManager.CreateLogFile(r.RelPath)
-		res := processSingleReset(r, branch, mode, remote, logFile)
+		var res ResetResult
+		if entry, err := backup.capture(r, mode == "hard"); err != nil {
+			logBackupFailure(logFile, err)
+			res = ResetResult{RelPath: r.RelPath, Error: "backup failed: " + err.Error()}
+		} else {
+			res = cfg.resetOne(ctx, r, branch, mode, logFile, nil)
+			backup.keep(entry, res.Skipped)
+		}
 		if logFile != nil {
 			_ = logFile.Close()
 		}
@@ -77,10 +107,12 @@ func syncBranch(ctx context.Context, root, branch, mode string, workers int, cfg
 		return res
 	})
 
-	var succeeded, failed, skipped int
+	var succeeded, failed, skipped, cancelled int
 	skipReasons := make(map[string]int)
 	for _, res := range results {
 		switch {
+		case res.Cancelled:
+			cancelled++
 		case res.Skipped:
 			skipped++
 			skipReasons[res.SkipReason]++
@@ -92,6 +124,19 @@ func syncBranch(ctx context.Context, root, branch, mode string, workers int, cfg
 	}
 
 	stop()
+	logManager.finish()
+
+	reportCI(cfg, opDesc, logManager, results)
+
+	if err := backup.save(); err != nil {
+		fmt.Fprintln(cfg.infoWriter(), StyleFailed.Render(fmt.Sprintf("Warning: could not save run journal: %v", err)))
+	}
+
+	if out.enabled() {
+		reportTimings(cfg, results)
+		backup.report(cfg)
+		return out.finishRun(failed, cancelled)
+	}
 
 	fmt.Println("\n" + StyleBold.Render("--- Summary ---"))
 	fmt.Printf("Ran '%s' across %d repos:\n", opDesc, len(repos))
@@ -109,18 +154,19 @@ func syncBranch(ctx context.Context, root, branch, mode string, workers int, cfg
 		}
 		fmt.Printf("  %s skipped (%s)\n", StyleSkipped.Render(fmt.Sprintf("%d", skipped)), strings.Join(parts, ", "))
 	}
+	if cancelled > 0 {
+		fmt.Printf("  %s cancelled\n", StyleDim.Render(fmt.Sprintf("%d", cancelled)))
+	}
+	reportTimings(cfg, results)
+	backup.report(cfg)
 
 	if PromptViewLogs() {
 		DisplayResetLogs(logManager, results)
 	} else {
-		fmt.Printf("\nLogs are available at: %s\n", logManager.GetTempDir())
-		fmt.Println("You can review them later if needed.")
+		printLogLocation(logManager)
 	}
 
-	if failed > 0 {
-		return errReposFailed
-	}
-	return nil
+	return runError(failed, cancelled)
 }
 
 func operationDescription(mode, branch, remote string) string {
@@ -150,30 +196,61 @@ func preflightScan(ctx context.Context, repos []RepoInfo, workers int) []repoPre
 	return dirty
 }
 
-func getDirtyStatus(dir string) string {
-	cmd := exec.Command("git", "status", "--porcelain")
+type porcelainCounts struct {
+	Staged     int
+	Unstaged   int
+	Untracked  int
+	Conflicted int
+}
+
+func (c porcelainCounts) dirty() bool {
+	return c.Staged+c.Unstaged+c.Untracked+c.Conflicted > 0
+}
+
+// countPorcelain tallies `git status --porcelain` lines; unmerged entries
+// (both sides touched, or either side U) count only as conflicted.
+func countPorcelain(out string) porcelainCounts {
+	var c porcelainCounts
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		x, y := line[0], line[1]
+		switch {
+		case x == '?' && y == '?':
+			c.Untracked++
+		case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+			c.Conflicted++
+		default:
+			if x != ' ' {
+				c.Staged++
+			}
+			if y != ' ' {
+				c.Unstaged++
+			}
+		}
+	}
+	return c
+}
+
+func getPorcelainCounts(dir string) (porcelainCounts, error) {
+	cmd := newCmd("git", "status", "--porcelain")
 	cmd.Dir = dir
 	out, err := cmd.Output()
 	if err != nil {
-		return ""
+		return porcelainCounts{}, err
 	}
-	trimmed := strings.TrimSpace(string(out))
-	if trimmed == "" {
+	return countPorcelain(string(out)), nil
+}
+
+func getDirtyStatus(dir string) string {
+	c, err := getPorcelainCounts(dir)
+	if err != nil || !c.dirty() {
 		return ""
 	}
 
-	hasStaged, hasUnstaged := false, false
-	for _, line := range strings.Split(trimmed, "\n") {
-		if len(line) < 2 {
-			continue
-		}
-		if line[0] != ' ' && line[0] != '?' {
-			hasStaged = true
-		}
-		if line[1] != ' ' {
-			hasUnstaged = true
-		}
-	}
+	hasStaged := c.Staged+c.Conflicted > 0
+	hasUnstaged := c.Unstaged+c.Untracked+c.Conflicted > 0
 
 	switch {
 	case hasStaged && hasUnstaged:
@@ -186,7 +263,22 @@ func getDirtyStatus(dir string) string {
 	return "changes"
 }
 
+func (cfg *Config) resetOne(ctx context.Context, r RepoInfo, branch, mode string, logFile *os.File, plan *RepoPlan) ResetResult {
+	target := cfg.targetFor(r.RelPath, branch)
+	if target == "" {
+		return ResetResult{RelPath: r.RelPath, Skipped: true, SkipReason: "no revision in manifest"}
+	}
+	if branch == "" && isPinnedRevision(target) {
+		return ResetResult{RelPath: r.RelPath, Skipped: true, SkipReason: "manifest pins a commit or tag, not a branch"}
+	}
+	return resetRepo(ctx, r, target, mode, cfg.remoteFor(r.RelPath), logFile, plan)
+}
+
 func processSingleReset(repo RepoInfo, branch, mode, remote string, logFile *os.File) ResetResult {
+	return resetRepo(context.Background(), repo, branch, mode, remote, logFile, nil)
+}
+
+func resetRepo(ctx context.Context, repo RepoInfo, branch, mode, remote string, logFile *os.File, plan *RepoPlan) ResetResult {
 	log := func(format string, args ...any) {
 		if logFile != nil {
 			_, _ = fmt.Fprintf(logFile, format+"\n", args...)
@@ -212,52 +304,57 @@ func processSingleReset(repo RepoInfo, branch, mode, remote string, logFile *os.
 		return ResetResult{RelPath: repo.RelPath, Skipped: true, SkipReason: "detached HEAD"}
 	}
 
-	found, netErr := checkBranchOnRemote(repo.Path, branch, remote)
+	remoteHead, netErr := remoteBranchHead(ctx, repo.Path, branch, remote)
 	if netErr != nil {
 		log("Error checking remote branch: %v", netErr)
 		return ResetResult{RelPath: repo.RelPath, Success: false, Error: fmt.Sprintf("network error: %v", netErr)}
 	}
-	if !found {
+	if remoteHead == "" {
 		log("Skipping: branch not on %s", remote)
 		return ResetResult{RelPath: repo.RelPath, Skipped: true, SkipReason: "branch not on " + remote}
 	}
 
 	log("Fetching to update %s/%s ref", remote, branch)
-	if fetchErr := fetchBranchFromRemote(repo.Path, branch, remote, logFile); fetchErr != nil {
+	if fetchErr := fetchBranchFromRemote(ctx, repo.Path, branch, remote, logFile, plan); fetchErr != nil {
 		log("Fetch failed: %v", fetchErr)
 		return ResetResult{RelPath: repo.RelPath, Success: false, Error: "fetch failed"}
 	}
 
-	if mode == "soft" && checkAlreadyAtTarget(repo.Path, branch, remote) {
-		log("Skipping: already up to date")
-		return ResetResult{RelPath: repo.RelPath, Skipped: true, SkipReason: "already up to date"}
+	if mode == "soft" {
+		// A dry run doesn't fetch, so it compares HEAD with the branch as
+		// ls-remote saw it rather than the possibly stale tracking ref.
+		var upToDate bool
+		if plan != nil {
+			head, _ := gitOutput(repo.Path, "rev-parse", "HEAD")
+			upToDate = head == remoteHead
+		} else {
+			upToDate = checkAlreadyAtTarget(ctx, repo.Path, branch, remote)
+		}
+		if upToDate {
+			log("Skipping: already up to date")
+			return ResetResult{RelPath: repo.RelPath, Skipped: true, SkipReason: "already up to date"}
+		}
 	}
 
 	switch mode {
 	case "hard":
-		return doHardReset(repo, branch, remote, logFile, log)
+		return doHardReset(ctx, repo, branch, remote, logFile, plan, log)
 	case "soft":
-		return doSoftReset(repo, branch, remote, logFile, log)
+		return doSoftReset(ctx, repo, branch, remote, logFile, plan, log)
 	case "rebase":
-		return doRebase(repo, branch, remote, logFile, log)
+		return doRebase(ctx, repo, branch, remote, logFile, plan, log)
 	}
 	return ResetResult{RelPath: repo.RelPath, Success: false, Error: "unknown mode"}
 }
 
-func doHardReset(repo RepoInfo, branch, remote string, logFile *os.File, log func(string, ...any)) ResetResult {
+func doHardReset(ctx context.Context, repo RepoInfo, branch, remote string, logFile *os.File, plan *RepoPlan, log func(string, ...any)) ResetResult {
 	if inProgress, opName := checkMidOperation(repo.Path); inProgress {
 		log("Skipping: mid-%s operation in progress", opName)
 		return ResetResult{RelPath: repo.RelPath, Skipped: true, SkipReason: fmt.Sprintf("mid-%s in progress", opName)}
 	}
 
 	log("Executing: git reset --hard %s/%s", remote, branch)
-	cmd := exec.Command("git", "reset", "--hard", remote+"/"+branch)
-	cmd.Dir = repo.Path
-	if logFile != nil {
-		cmd.Stdout = logFile
-		cmd.Stderr = logFile
-	}
-	if err := cmd.Run(); err != nil {
+	if err := gitStep(ctx, repo.Path, logFile, plan, "reset", "--hard", remote+"/"+branch); err != nil {
 		log("Hard reset failed: %v", err)
 		return ResetResult{RelPath: repo.RelPath, Success: false, Error: "reset --hard failed"}
 	}
@@ -265,9 +362,9 @@ func doHardReset(repo RepoInfo, branch, remote string, logFile *os.File, log fun
 	return ResetResult{RelPath: repo.RelPath, Success: true}
 }
 
-func doSoftReset(repo RepoInfo, branch, remote string, logFile *os.File, log func(string, ...any)) ResetResult {
+func doSoftReset(ctx context.Context, repo RepoInfo, branch, remote string, logFile *os.File, plan *RepoPlan, log func(string, ...any)) ResetResult {
 	warning := ""
-	stagedCheck := exec.Command("git", "diff", "--cached", "--quiet")
+	stagedCheck := newCmdContext(ctx, "git", "diff", "--cached", "--quiet")
 	stagedCheck.Dir = repo.Path
 	if stagedCheck.Run() != nil {
 		warning = "had staged changes before reset"
@@ -275,13 +372,7 @@ func doSoftReset(repo RepoInfo, branch, remote string, logFile *os.File, log fun
 	}
 
 	log("Executing: git reset --soft %s/%s", remote, branch)
-	cmd := exec.Command("git", "reset", "--soft", remote+"/"+branch)
-	cmd.Dir = repo.Path
-	if logFile != nil {
-		cmd.Stdout = logFile
-		cmd.Stderr = logFile
-	}
-	if err := cmd.Run(); err != nil {
+	if err := gitStep(ctx, repo.Path, logFile, plan, "reset", "--soft", remote+"/"+branch); err != nil {
 		log("Soft reset failed: %v", err)
 		return ResetResult{RelPath: repo.RelPath, Success: false, Error: "reset --soft failed"}
 	}
@@ -289,7 +380,7 @@ func doSoftReset(repo RepoInfo, branch, remote string, logFile *os.File, log fun
 	return ResetResult{RelPath: repo.RelPath, Success: true, Warning: warning}
 }
 
-func doRebase(repo RepoInfo, branch, remote string, logFile *os.File, log func(string, ...any)) ResetResult {
+func doRebase(ctx context.Context, repo RepoInfo, branch, remote string, logFile *os.File, plan *RepoPlan, log func(string, ...any)) ResetResult {
 	if checkRebaseInProgress(repo.Path) {
 		log("Skipping: rebase already in progress")
 		return ResetResult{RelPath: repo.RelPath, Skipped: true, SkipReason: "rebase already in progress"}
@@ -300,16 +391,12 @@ func doRebase(repo RepoInfo, branch, remote string, logFile *os.File, log func(s
 		return ResetResult{RelPath: repo.RelPath, Success: false, Error: "working tree must be clean"}
 	}
 
+	// Neither the rebase nor its abort is bound to the run context, so
+	// Ctrl-C never leaves a repo stuck mid-rebase.
 	log("Executing: git rebase %s/%s", remote, branch)
-	cmd := exec.Command("git", "rebase", remote+"/"+branch)
-	cmd.Dir = repo.Path
-	if logFile != nil {
-		cmd.Stdout = logFile
-		cmd.Stderr = logFile
-	}
-	if err := cmd.Run(); err != nil {
+	if err := gitStep(context.Background(), repo.Path, logFile, plan, "rebase", remote+"/"+branch); err != nil {
 		log("Rebase failed: %v, aborting...", err)
-		abortCmd := exec.Command("git", "rebase", "--abort")
+		abortCmd := newCmd("git", "rebase", "--abort")
 		abortCmd.Dir = repo.Path
 		if logFile != nil {
 			abortCmd.Stdout = logFile
@@ -343,19 +430,19 @@ func resolveRemoteAndBranch(dir, branchArg, defaultRemote string) (remote, branc
 }
 
 func checkRemoteExists(dir, remote string) bool {
-	cmd := exec.Command("git", "remote", "get-url", remote)
+	cmd := newCmd("git", "remote", "get-url", remote)
 	cmd.Dir = dir
 	return cmd.Run() == nil
 }
 
 func checkHasCommits(dir string) bool {
-	cmd := exec.Command("git", "rev-parse", "--verify", "HEAD")
+	cmd := newCmd("git", "rev-parse", "--verify", "HEAD")
 	cmd.Dir = dir
 	return cmd.Run() == nil
 }
 
 func checkDetachedHEAD(dir string) bool {
-	cmd := exec.Command("git", "branch", "--show-current")
+	cmd := newCmd("git", "branch", "--show-current")
 	cmd.Dir = dir
 	out, err := cmd.Output()
 	if err != nil {
@@ -365,23 +452,35 @@ func checkDetachedHEAD(dir string) bool {
 }
 
 func checkBranchOnRemote(dir, branch, remote string) (bool, error) {
-	cmd := exec.Command("git", "ls-remote", "--exit-code", "--heads", remote, branch)
+	head, err := remoteBranchHead(context.Background(), dir, branch, remote)
+	return head != "", err
+}
+
+// remoteBranchHead asks the remote for the SHA of branch, and returns ""
+// when the remote doesn't have it.
+func remoteBranchHead(ctx context.Context, dir, branch, remote string) (string, error) {
+	cmd := newCmdContext(ctx, "git", "ls-remote", "--exit-code", "--heads", remote, branch)
 	cmd.Dir = dir
-	err := cmd.Run()
-	if err == nil {
-		return true, nil
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if exitErr.ExitCode() == 2 {
+				return "", nil
+			}
+			return "", fmt.Errorf("ls-remote failed with exit code %d", exitErr.ExitCode())
+		}
+		return "", err
 	}
-	if exitErr, ok := err.(*exec.ExitError); ok {
-		if exitErr.ExitCode() == 2 {
-			return false, nil
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if sha, ref, ok := strings.Cut(line, "\t"); ok && ref == "refs/heads/"+branch {
+			return sha, nil
 		}
-		return false, fmt.Errorf("ls-remote failed with exit code %d", exitErr.ExitCode())
 	}
-	return false, err
+	return "", nil
 }
 
 func getCurrentBranch(dir string) (string, error) {
-	cmd := exec.Command("git", "branch", "--show-current")
+	cmd := newCmd("git", "branch", "--show-current")
 	cmd.Dir = dir
 	out, err := cmd.Output()
 	if err != nil {
@@ -416,15 +515,15 @@ func checkRebaseInProgress(dir string) bool {
 	return false
 }
 
-func checkAlreadyAtTarget(dir, branch, remote string) bool {
-	headCmd := exec.Command("git", "rev-parse", "HEAD")
+func checkAlreadyAtTarget(ctx context.Context, dir, branch, remote string) bool {
+	headCmd := newCmdContext(ctx, "git", "rev-parse", "HEAD")
 	headCmd.Dir = dir
 	headOut, err := headCmd.Output()
 	if err != nil {
 		return false
 	}
 
-	remoteCmd := exec.Command("git", "rev-parse", remote+"/"+branch)
+	remoteCmd := newCmdContext(ctx, "git", "rev-parse", remote+"/"+branch)
 	remoteCmd.Dir = dir
 	remoteOut, err := remoteCmd.Output()
 	if err != nil {
@@ -434,8 +533,8 @@ func checkAlreadyAtTarget(dir, branch, remote string) bool {
 	return strings.TrimSpace(string(headOut)) == strings.TrimSpace(string(remoteOut))
 }
 
-func fetchBranchFromRemote(dir, branch, remote string, logFile *os.File) error {
-	checkCmd := exec.Command("git", "rev-parse", "--is-shallow-repository")
+func fetchBranchFromRemote(ctx context.Context, dir, branch, remote string, logFile *os.File, plan *RepoPlan) error {
+	checkCmd := newCmdContext(ctx, "git", "rev-parse", "--is-shallow-repository")
 	checkCmd.Dir = dir
 	shallowOut, shallowErr := checkCmd.Output()
 	isShallow := shallowErr == nil && strings.TrimSpace(string(shallowOut)) == "true"
@@ -446,13 +545,7 @@ func fetchBranchFromRemote(dir, branch, remote string, logFile *os.File) error {
 	}
 	args = append(args, branch)
 
-	fetchCmd := exec.Command("git", args...)
-	fetchCmd.Dir = dir
-	if logFile != nil {
-		fetchCmd.Stdout = logFile
-		fetchCmd.Stderr = logFile
-	}
-	if err := fetchCmd.Run(); err != nil {
+	if err := gitStep(ctx, dir, logFile, plan, args...); err != nil {
 		return fmt.Errorf("fetch failed")
 	}
 	return nil
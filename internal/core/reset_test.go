@@ -191,14 +191,14 @@ func TestCheckBranchOnRemote(t *testing.T) {
 func TestCheckAlreadyAtTarget(t *testing.T) {
 	t.Run("already at target", func(t *testing.T) {
 		repoDir, _ := makeRepoWithRemote(t)
-		if !checkAlreadyAtTarget(repoDir, "main", "origin") {
+		if !checkAlreadyAtTarget(context.Background(), repoDir, "main", "origin") {
 			t.Error("expected already at origin/main")
 		}
 	})
 
 	t.Run("ahead of target", func(t *testing.T) {
 		repoDir, _ := makeRepoAheadOfOrigin(t)
-		if checkAlreadyAtTarget(repoDir, "main", "origin") {
+		if checkAlreadyAtTarget(context.Background(), repoDir, "main", "origin") {
 			t.Error("expected NOT at origin/main (local is ahead)")
 		}
 	})
@@ -429,7 +429,7 @@ func TestProcessSingleResetSoftMovesHEAD(t *testing.T) {
 		t.Fatalf("expected Success=true, got error: %q", res.Error)
 	}
 
-	if !checkAlreadyAtTarget(repoDir, "main", "origin") {
+	if !checkAlreadyAtTarget(context.Background(), repoDir, "main", "origin") {
 		t.Error("expected HEAD to be at origin/main after soft reset")
 	}
 
@@ -466,7 +466,7 @@ func TestProcessSingleResetHardDiscardsChanges(t *testing.T) {
 		t.Fatalf("expected Success=true, got error: %q", res.Error)
 	}
 
-	if !checkAlreadyAtTarget(repoDir, "main", "origin") {
+	if !checkAlreadyAtTarget(context.Background(), repoDir, "main", "origin") {
 		t.Error("expected HEAD to be at origin/main after hard reset")
 	}
 
@@ -628,7 +628,7 @@ func TestProcessSingleResetFetchWhenAlreadyOnBranch(t *testing.T) {
 			res.Error, res.Skipped, res.SkipReason)
 	}
 
-	if !checkAlreadyAtTarget(repoDir, "main", "origin") {
+	if !checkAlreadyAtTarget(context.Background(), repoDir, "main", "origin") {
 		t.Error("expected HEAD to be at origin/main after soft reset")
 	}
 }
@@ -739,3 +739,36 @@ func TestSyncBranchSoftMixedOutcomes(t *testing.T) {
 		t.Errorf("expected '1 skipped' in output, got: %s", output)
 	}
 }
+
+func TestHardResetWithOutputNeedsYes(t *testing.T) {
+	tmpDir := t.TempDir()
+	remote := filepath.Join(tmpDir, "remote.git")
+	repoDir := filepath.Join(tmpDir, "repo1")
+	createDir(t, remote)
+	runCmd(t, remote, "git", "init", "--bare", "-b", "main")
+	createGitRepo(t, repoDir)
+	runCmd(t, repoDir, "git", "remote", "add", "origin", remote)
+	runCmd(t, repoDir, "git", "push", "origin", "main")
+	writeFile(t, repoDir, "local.txt", "local content")
+	runCmd(t, repoDir, "git", "add", ".")
+	runCmd(t, repoDir, "git", "commit", "-m", "local commit ahead of origin")
+	local, _ := gitOutput(repoDir, "rev-parse", "HEAD")
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+	err := runQuiet(t, func() error { return syncBranch(context.Background(), tmpDir, "main", "hard", 2, cfg) })
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Fatalf("expected an error pointing at --yes, got %v", err)
+	}
+	if head, _ := gitOutput(repoDir, "rev-parse", "HEAD"); head != local {
+		t.Errorf("expected the repo to be untouched, HEAD moved to %s", head)
+	}
+
+	cfg.Yes = true
+	if err := runQuiet(t, func() error { return syncBranch(context.Background(), tmpDir, "main", "hard", 2, cfg) }); err != nil {
+		t.Fatalf("expected --yes to skip the confirmation, got %v", err)
+	}
+	if head, _ := gitOutput(repoDir, "rev-parse", "HEAD"); head == local {
+		t.Error("expected --yes to reset the repo")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
@@ -35,9 +36,30 @@ type Config struct {
 	excludeBranchPats []string
 	includeBranchSet  map[string]struct{}
 	includeBranchPats []string
+	groups            map[string][]string
 	PageSize          int
 	IncludeWorktrees  bool
 	Remote            string
+	Output            string
+	Progress          string
+	CIFormat          string
+	CIMaxBehind       int
+	JUnit             string
+	Timings           bool
+	DryRun            bool
+	Yes               bool
+	Force             bool
+	Stream            bool
+	GroupOutput       bool
+	MaxFailures       int
+	infoToStderr      bool
+	manifest          *Manifest
+	remoteFromFlag    bool
+	worktreeBase      string
+	overrides         []repoOverride
+	interrupts        *interrupter
+	args              []string
+	fromRun           *runSelection
 }
 
 func hasGlobMeta(s string) bool {
@@ -66,17 +88,27 @@ func matchesGlob(pattern, slashPath string) bool {
 	return false
 }
 
-func newConfig(excludeDirs, includeDirs, excludeBranches, includeBranches []string, pageSize int, includeWorktrees bool, remote string) (*Config, error) {
+func newConfig(excludeDirs, includeDirs, excludeBranches, includeBranches []string, pageSize int, includeWorktrees bool, remote string, groups map[string][]string) (*Config, error) {
 	cfg := &Config{
 		excludeSet:       make(map[string]struct{}),
 		includeSet:       make(map[string]struct{}),
 		excludeBranchSet: make(map[string]struct{}),
 		includeBranchSet: make(map[string]struct{}),
+		groups:           groups,
 		PageSize:         pageSize,
 		IncludeWorktrees: includeWorktrees,
 		Remote:           remote,
 	}
 
+	includeDirs, err := expandGroups(includeDirs, groups)
+	if err != nil {
+		return nil, err
+	}
+	excludeDirs, err = expandGroups(excludeDirs, groups)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, dir := range includeDirs {
 		if hasGlobMeta(dir) {
 			if _, err := path.Match(dir, ""); err != nil {
@@ -242,13 +274,22 @@ func reorderArgs(args []string) []string {
 		if strings.HasPrefix(arg, "-") {
 			flags = append(flags, arg)
 			boolFlags := map[string]bool{
-				"-l": true, "--list": true,
-				"-v": true, "--version": true,
-				"-h": true, "--help": true,
-				"-iw": true, "--include-worktrees": true,
-				"-wl": true, "--worktree-list": true,
+				"-l": true, "--list": true, "-list": true,
+				"-v": true, "--version": true, "-version": true,
+				"-h": true, "--help": true, "-help": true,
+				"-iw": true, "--include-worktrees": true, "-include-worktrees": true,
+				"-wl": true, "--worktree-list": true, "-worktree-list": true,
+				"-tr": true, "--track": true, "-track": true,
+				"-n": true, "--dry-run": true, "-dry-run": true,
+				"-y": true, "--yes": true, "-yes": true,
+				"--force": true, "-force": true,
+				"--stream": true, "-stream": true,
+				"--group-output": true, "-group-output": true,
+				"--fail-fast": true, "-fail-fast": true,
+				"--retry-failed": true, "-retry-failed": true,
+				"--timings": true, "-timings": true,
 			}
-			if !boolFlags[arg] && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			if !boolFlags[arg] && !strings.Contains(arg, "=") && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
 				i++
 				flags = append(flags, args[i])
 			}
@@ -262,11 +303,18 @@ func reorderArgs(args []string) []string {
 }
 
 // Go's flag package requires a value for string flags; inject "" so -dv can be used without a branch argument.
-func injectDivergeDefault(args []string) []string {
+var optionalValueFlags = map[string]bool{
+	"-dv": true, "--diverge": true, "-diverge": true,
+	"-rs": true, "--reset-soft": true, "-reset-soft": true,
+	"-rh": true, "--reset-hard": true, "-reset-hard": true,
+	"-rb": true, "--rebase": true, "-rebase": true,
+}
+
+func injectOptionalValues(args []string) []string {
 	result := make([]string, 0, len(args)+1)
 	for i, arg := range args {
 		result = append(result, arg)
-		if arg == "-dv" || arg == "--diverge" || arg == "-diverge" {
+		if optionalValueFlags[arg] {
 			if i+1 >= len(args) || strings.HasPrefix(args[i+1], "-") {
 				result = append(result, "")
 			}
@@ -276,8 +324,15 @@ func injectDivergeDefault(args []string) []string {
 }
 
 func Run(ctx context.Context, args []string) error {
+	// Everything after "--" is a raw git argv and must not be reordered or parsed as flags.
+	var gitArgv []string
+	if i := slices.Index(args, "--"); i >= 0 {
+		gitArgv = args[i+1:]
+		args = args[:i]
+	}
+
 	args = reorderArgs(args)
-	args = injectDivergeDefault(args)
+	args = injectOptionalValues(args)
 
 	fs := flag.NewFlagSet("gb", flag.ContinueOnError)
 
@@ -344,16 +399,67 @@ func Run(ctx context.Context, args []string) error {
 	trackUpstream := fs.Bool("track", false, "Show upstream tracking branch for each repo's current branch")
 	fs.BoolVar(trackUpstream, "tr", false, "Show upstream tracking (shorthand)")
 
+	outputFormat := fs.String("output", "", "Machine-readable output format: json or ndjson")
+	fs.StringVar(outputFormat, "o", "", "Machine-readable output format (shorthand)")
+
+	manifestFlag := fs.String("manifest", "", "Use the repos listed in a gb or repo-tool XML manifest instead of scanning directories")
+	fs.StringVar(manifestFlag, "m", "", "Repo manifest to use instead of scanning (shorthand)")
+
+	dryRun := fs.Bool("dry-run", false, "Show the per-repo plan for switch, reset/rebase and worktree create/remove without changing anything")
+	fs.BoolVar(dryRun, "n", false, "Show the per-repo plan without changing anything (shorthand)")
+
+	yes := fs.Bool("yes", false, "Skip the confirmation before -rh, -rb and gb undo")
+	fs.BoolVar(yes, "y", false, "Skip the confirmation (shorthand)")
+
+	force := fs.Bool("force", false, "With gb undo, also restore repos that are dirty or have moved since the run")
+
+	stream := fs.Bool("stream", false, "Print -c/-sh output live, each line prefixed with its repo")
+
+	groupOutput := fs.Bool("group-output", false, "After -c/-sh, print each distinct output once with the repos that produced it")
+
+	failFast := fs.Bool("fail-fast", false, "Stop starting new repos after the first failure")
+
+	maxFailures := fs.Int("max-failures", 0, "Stop starting new repos after N failures (0 = never)")
+
+	fromLast := fs.String("from-last", "", "Only use repos with this outcome (failed, skipped, succeeded) in the last run, or <outcome>@<run-id>")
+
+	retryFailed := fs.Bool("retry-failed", false, "Rerun the last run's command on the repos that failed in it")
+
+	progressMode := fs.String("progress", "", "Progress display: tui, plain, quiet or none (default: tui on a terminal, plain otherwise)")
+
+	ciFormat := fs.String("ci-format", "", "Annotate failed and skipped repos for CI: github or gitlab")
+
+	ciMaxBehind := fs.Int("ci-max-behind", 0, "With --ci-format and -dv, annotate repos more than N commits behind")
+
+	junitFile := fs.String("junit", "", "Write a JUnit XML report of per-repo results to this file")
+
+	timings := fs.Bool("timings", false, "Print how long each repo took, slowest first")
+
+	statusOnly := fs.String("only", "", "Limit gb status to repos that are dirty, behind, ahead or conflicted (comma-separated)")
+
 	fs.Usage = func() {
-		_, _ = fmt.Fprintf(fs.Output(), "Usage: gb [options] <branch_name>\n\n")
+		_, _ = fmt.Fprintf(fs.Output(), "Usage: gb [options] <branch_name>\n       gb [options] -- <git args>\n\n")
 		fmt.Println("Options:")
 		fmt.Println("  -h, --help              Show this help message")
 		fmt.Println("  -v, --version           Show version information")
 		fmt.Println("  -l, --list              List all branches found in repositories")
 		fmt.Println("  -dv, --diverge [branch] Show ahead/behind commit counts vs <remote>/<branch> (omit branch to use each repo's tracked branch)")
 		fmt.Println("  -tr, --track            Show upstream tracking branch for each repo's current branch")
-		fmt.Println("  -c, --cmd string        Execute a git command in all repositories")
+		fmt.Println("  -c, --cmd string        Execute a git command in all repositories (quotes and backslash escapes work as in a shell)")
+		fmt.Println("  -- <git args>           Execute the git command given after -- verbatim, with no extra quoting")
+		fmt.Println("                          -c, -sh and -- accept {{.RelPath}}, {{.Name}}, {{.Path}}, {{.Branch}}, {{.Upstream}},")
+		fmt.Println("                          {{.Remote}} and {{.HeadSHA}}; -sh also exports them as GB_REL_PATH, GB_NAME, ...")
 		fmt.Println("  -sh, --shell string     Execute a shell command in all repositories")
+		fmt.Println("  --stream                Print -c/-sh output live as [repo]-prefixed lines (an output pane under the progress TUI)")
+		fmt.Println("  --group-output          After -c/-sh, print each distinct output once with its repos, largest group first")
+		fmt.Println("  --fail-fast             Stop starting new repos after the first failure; the rest are reported as cancelled")
+		fmt.Println("  --max-failures int      Stop starting new repos after N failures (default 0: never)")
+		fmt.Println("                          Ctrl-C once also stops starting new repos; press it again to kill running commands")
+		fmt.Println("                          In the progress view, / filters, f cycles statuses, r requeues, l shows the log, x cancels")
+		fmt.Println("  --from-last string      Only use repos that failed, skipped or succeeded in the last run (comma-separated;")
+		fmt.Println("                          add @<run-id> to pick a run: failed@20261016-153012-a1b2c3)")
+		fmt.Println("  --retry-failed          Rerun the last run's command on the repos that failed in it (alone), or")
+		fmt.Println("                          --from-last failed when given with a command")
 		fmt.Println("  -w, --workers int       Number of concurrent workers (default 20)")
 		fmt.Println("  -ps, --size int         Number of repos to display per page (default 20)")
 		fmt.Println("  -e, --excludeDirs string   Comma-separated list of directories to exclude from execution")
@@ -362,17 +468,49 @@ func Run(ctx context.Context, args []string) error {
 		fmt.Println("  -rs, --reset-soft string  Soft reset all repos to <remote>/<branch>")
 		fmt.Println("  -rh, --reset-hard string  Hard reset all repos to <remote>/<branch> (destructive, confirms first)")
 		fmt.Println("  -rb, --rebase string      Rebase all repos onto <remote>/<branch> (confirms first)")
+		fmt.Println("  -y, --yes                 Skip the confirmation before -rh, -rb and gb undo (required with -o or without a terminal)")
+		fmt.Println("  --force                   With gb undo, also restore repos that are dirty or have moved since the run")
 		fmt.Println("  -ib, --includeBranches string")
 		fmt.Println("                            Only operate on repos currently on these branches (comma-separated)")
 		fmt.Println("  -eb, --excludeBranches string")
 		fmt.Println("                            Exclude repos currently on these branches (comma-separated)")
 		fmt.Println("  -r, --remote string         Remote name to use for fetch/rebase/reset (default: origin)")
 		fmt.Println("  -iw, --include-worktrees  Include worktree repos in operations (default: excluded)")
+		fmt.Println("  -o, --output string       Emit results as json or ndjson (disables the TUI and prompts)")
+		fmt.Println("  --progress string         Progress display: tui, plain, quiet or none (default: tui on a terminal, plain otherwise)")
+		fmt.Println("  --ci-format string        Emit github or gitlab annotations and log sections for failed/skipped repos")
+		fmt.Println("                            (-c, -sh, switch, -rs/-rh/-rb, -dv); also writes $GITHUB_STEP_SUMMARY when set")
+		fmt.Println("  --ci-max-behind int       With --ci-format and -dv, annotate repos more than N commits behind (default 0)")
+		fmt.Println("  --junit string            Write a JUnit XML report with one testcase per repo")
+		fmt.Println("  --timings                 Print each repo's duration and retries after the summary, slowest first")
+		fmt.Println("                            Timings are kept per command, so its next run starts the slowest repos first and shows an ETA")
+		fmt.Println("  -m, --manifest string     Use the repos in a gb YAML or repo-tool XML manifest instead of scanning directories")
+		fmt.Println("  -n, --dry-run             Print the git commands each repo would run (switch, reset/rebase, worktree create/remove) without changing anything")
 		fmt.Println("\nWorktree Commands:")
 		fmt.Println("  -wl, --worktree-list              List all active worktrees across all repos")
-		fmt.Println("  -wc, --worktree-create string     Create worktrees for <branch> (optional base as positional arg, default master)")
+		fmt.Println("  -wc, --worktree-create string     Create worktrees for <branch> (optional base as positional arg, default master or worktreeBase from config)")
 		fmt.Println("  -wr, --worktree-remove string     Remove worktrees for <branch> across all repos (glob patterns supported: *, ?, [...])")
 		fmt.Println("  -wo, --worktree-open string       Print worktree paths for <branch> across all repos")
+		fmt.Println("\nCommands:")
+		fmt.Println("  gb status [--only filter]         Show branch, dirty counts, stashes, ahead/behind and in-progress operations per repo")
+		fmt.Println("                                    (filters: dirty, behind, ahead, conflicted; comma-separated)")
+		fmt.Println("  gb groups                         List named repo groups and the repos each expands to")
+		fmt.Println("  gb history                        List recent switch/reset/rebase runs and the repos they changed")
+		fmt.Println("  gb logs [run-id|last] [repo]      List recent runs, show one run's per-repo results, or print a repo's log")
+		fmt.Println("  gb logs failed [run-id|last]      Print the logs of the repos that failed in the last (or given) run")
+		fmt.Println("  gb undo [run-id]                  Restore every repo changed by a run (default: the latest run not yet undone)")
+		fmt.Println("                                    Repos that are dirty or have moved since the run are skipped unless --force is given")
+		fmt.Println("  gb snapshot save <file>           Write each repo's remotes, branch, HEAD and dirty flag to a lockfile")
+		fmt.Println("  gb snapshot restore <file>        Fetch missing commits and check out each repo at its recorded branch/HEAD")
+		fmt.Println("  gb snapshot diff <a> <b>          Show which repos moved between two snapshots")
+		fmt.Println("  gb clone <manifest>               Clone all missing repos from a manifest; verify the ones already present")
+		fmt.Println("  gb manifest export [file]         Write a manifest of the discovered repos (stdout if no file)")
+		fmt.Println("\nConfiguration:")
+		fmt.Println("  Defaults are read from $XDG_CONFIG_HOME/gb/config.yaml and the nearest .gb.yaml")
+		fmt.Println("  found walking up from the current directory. Command-line flags always win.")
+		fmt.Println("  Named groups defined there can be selected with -i @group or -e @group.")
+		fmt.Println("  With a manifest (-m or 'manifest:' in config), its groups work the same way and")
+		fmt.Println("  -rs/-rh/-rb or a switch without a branch use each project's remote and revision.")
 		fmt.Println("\nExamples:")
 		fmt.Println("  gb main                      Switch all repos to main branch")
 		fmt.Println("  gb -l                        List all current branches")
@@ -380,17 +518,24 @@ func Run(ctx context.Context, args []string) error {
 		fmt.Println("  gb -w 50 -l                  Fast branch listing with 50 workers")
 		fmt.Println("  gb --workers 5 main          Switch with 5 concurrent workers")
 		fmt.Println("  gb -i \"vendor,custom\" 15.0   Execute only in vendor and custom directories")
+		fmt.Println("  gb -i @backend,@frontend -l  List branches only in repos of the backend and frontend groups")
 		fmt.Println("  gb -e \"build,temp\" -l        List branches, excluding build and temp directories")
 		fmt.Println("  gb -c \"status\"               Execute 'git status' in all repositories")
 		fmt.Println("  gb -c \"status\" -i \"abc,def\"  Execute 'git status' only in abc and def directories")
 		fmt.Println("  gb --cmd \"fetch origin\"     Execute 'git fetch origin' in all repositories")
+		fmt.Println("  gb -c 'commit -m \"fix typo\"'  Quoted words are passed to git as a single argument")
+		fmt.Println("  gb -- log --oneline -5       Execute 'git log --oneline -5' in all repositories")
 		fmt.Println("  gb -sh \"ls -la\"              Execute 'ls -la' shell command in all repositories")
 		fmt.Println("  gb -sh \"pwd\" -i \"vendor\"     Execute 'pwd' only in vendor directory")
 		fmt.Println("  gb --shell \"mkdir tmp\"      Execute 'mkdir tmp' shell command in all repositories")
+		fmt.Println("  gb -c 'push {{.Remote}} {{.Branch}}'          Push each repo's current branch to its remote")
+		fmt.Println("  gb -sh 'tar czf /backups/{{.Name}}.tgz .'     Archive each repo under its own name")
 		fmt.Println("  gb -rs main              Soft reset all repos to origin/main")
 		fmt.Println("  gb -rh feature/xyz       Hard reset all repos to origin/feature/xyz (with confirmation)")
 		fmt.Println("  gb -rb develop           Rebase all repos onto origin/develop (with confirmation)")
 		fmt.Println("  gb -rs main -r upstream  Soft reset all repos to upstream/main")
+		fmt.Println("  gb -rh 15.0 --dry-run    Show what a hard reset to origin/15.0 would do in each repo")
+		fmt.Println("  gb -m .repo/manifest.xml -rs   Soft reset every manifest project to its own remote/revision")
 		fmt.Println("  gb -ib main -l           List branches, only repos currently on main")
 		fmt.Println("  gb -eb main -c \"fetch origin\"  Fetch in all repos except those on main")
 		fmt.Println("  gb -ib develop -c \"status\"     Git status only in repos on develop")
@@ -409,6 +554,13 @@ func Run(ctx context.Context, args []string) error {
 		fmt.Println("  gb -dv origin/main           Explicit remote prefix for divergence check")
 		fmt.Println("  gb -dv main -r upstream      Check divergence against upstream/main")
 		fmt.Println("  gb -tr                       Show upstream tracking for all repos")
+		fmt.Println("  gb status --only dirty,behind  Show only repos with local changes or missing upstream commits")
+		fmt.Println("  gb -dv main -o json          Divergence report as a JSON document")
+		fmt.Println("  gb -c fetch -o ndjson        Stream one JSON record per repo as it completes")
+		fmt.Println("  gb -sh 'npm ci' --stream     Watch every repo's output live, tagged with its path")
+		fmt.Println("  gb -sh 'node --version' --group-output   Show which repos differ from the rest")
+		fmt.Println("  gb -c 'push' --max-failures 3  Give up on the remaining repos after three pushes fail")
+		fmt.Println("  gb --retry-failed              Rerun the last command on only the repos that failed")
 	}
 
 	if err := fs.Parse(args); err != nil {
@@ -423,21 +575,146 @@ func Run(ctx context.Context, args []string) error {
 		return nil
 	}
 
+	if err := validateOutputFormat(*outputFormat); err != nil {
+		return err
+	}
+	if err := validateProgressMode(*progressMode); err != nil {
+		return err
+	}
+	if *progressMode == progressTUI && *outputFormat != "" {
+		return fmt.Errorf("--progress=tui can't be combined with --output")
+	}
+	if err := validateCIFormat(*ciFormat); err != nil {
+		return err
+	}
+	if *ciMaxBehind < 0 {
+		return fmt.Errorf("--ci-max-behind must be 0 or more")
+	}
+	if *maxFailures < 0 {
+		return fmt.Errorf("--max-failures must be 0 or more")
+	}
+	if *retryFailed {
+		if *fromLast != "" {
+			return fmt.Errorf("use either --retry-failed or --from-last, not both")
+		}
+		if gitArgv == nil && fs.NArg() == 0 && !hasActionFlag(fs) {
+			return retryLastRun(ctx, args)
+		}
+		*fromLast = "failed"
+	}
+
+	var cmdArgs []string
+	if *runCommand != "" {
+		var err error
+		if cmdArgs, err = splitCommandLine(*runCommand); err != nil {
+			return err
+		}
+	}
+
+	root, _ := os.Getwd()
+	root = resolveRoot(root)
+
+	fileCfg, err := loadFileConfig(root)
+	if err != nil {
+		return err
+	}
+
 	excludeDirs := parseCommaSeparated(*excludeDirsFlag, defaultExcludeDirs)
 	includeDirs := parseCommaSeparated(*includeDirsFlag, nil)
 	excludeBranches := parseCommaSeparated(*excludeBranchesFlag, nil)
 	includeBranches := parseCommaSeparated(*includeBranchesFlag, nil)
 
-	cfg, err := newConfig(excludeDirs, includeDirs, excludeBranches, includeBranches, *pageSize, *includeWorktrees, *remoteName)
+	if !isFlagSet(fs, "workers", "w") && fileCfg.Workers != nil {
+		*workers = *fileCfg.Workers
+	}
+	if !isFlagSet(fs, "size", "ps") && fileCfg.PageSize != nil {
+		*pageSize = *fileCfg.PageSize
+	}
+	if !isFlagSet(fs, "remote", "r") && fileCfg.Remote != "" {
+		*remoteName = fileCfg.Remote
+	}
+	if !isFlagSet(fs, "excludeDirs", "e") && fileCfg.ExcludeDirs != nil {
+		excludeDirs = fileCfg.ExcludeDirs
+	}
+	if !isFlagSet(fs, "includeDirs", "i") && fileCfg.IncludeDirs != nil {
+		includeDirs = fileCfg.IncludeDirs
+	}
+	if !isFlagSet(fs, "excludeBranches", "eb") && fileCfg.ExcludeBranches != nil {
+		excludeBranches = fileCfg.ExcludeBranches
+	}
+	if !isFlagSet(fs, "includeBranches", "ib") && fileCfg.IncludeBranches != nil {
+		includeBranches = fileCfg.IncludeBranches
+	}
+	if !isFlagSet(fs, "include-worktrees", "iw") && fileCfg.IncludeWorktrees != nil {
+		*includeWorktrees = *fileCfg.IncludeWorktrees
+	}
+
+	groups := fileCfg.Groups
+	manifestPath := *manifestFlag
+	if manifestPath == "" {
+		manifestPath = fileCfg.Manifest
+	}
+	if fs.Arg(0) == "clone" {
+		if fs.NArg() != 2 {
+			return fmt.Errorf("usage: gb clone <manifest>")
+		}
+		manifestPath = fs.Arg(1)
+	}
+	var manifest *Manifest
+	if manifestPath != "" {
+		if manifest, err = readManifest(manifestPath); err != nil {
+			return err
+		}
+		groups = manifest.mergeGroups(groups)
+		if !isFlagSet(fs, "excludeDirs", "e") && fileCfg.ExcludeDirs == nil {
+			excludeDirs = nil
+		}
+	}
+
+	cfg, err := newConfig(excludeDirs, includeDirs, excludeBranches, includeBranches, *pageSize, *includeWorktrees, *remoteName, groups)
 	if err != nil {
 		return err
 	}
+	cfg.Output = *outputFormat
+	cfg.Progress = *progressMode
+	cfg.CIFormat = *ciFormat
+	cfg.CIMaxBehind = *ciMaxBehind
+	cfg.JUnit = *junitFile
+	cfg.Timings = *timings
+	cfg.DryRun = *dryRun
+	cfg.Yes = *yes
+	cfg.Force = *force
+	cfg.Stream = *stream
+	cfg.GroupOutput = *groupOutput
+	cfg.MaxFailures = *maxFailures
+	if *failFast {
+		cfg.MaxFailures = 1
+	}
+	cfg.remoteFromFlag = isFlagSet(fs, "remote", "r")
+	cfg.worktreeBase = fileCfg.WorktreeBase
+	cfg.overrides = fileCfg.Repos
+	cfg.manifest = manifest
+	cfg.interrupts = interrupterFrom(ctx)
+	cfg.args = args
+	if *fromLast != "" {
+		if cfg.fromRun, err = loadRunSelection(*fromLast); err != nil {
+			return err
+		}
+	}
+	defer cfg.interrupts.report(cfg.infoWriter())
 
-	root, _ := os.Getwd()
-	root = resolveRoot(root)
+	if gitArgv != nil {
+		if *runCommand != "" {
+			return fmt.Errorf("use either -c or -- <git args>, not both")
+		}
+		if len(gitArgv) == 0 {
+			return fmt.Errorf("no git command after --")
+		}
+		return executeCommandInRepos(ctx, root, gitArgv, *workers, cfg)
+	}
 
 	if *runCommand != "" {
-		return executeCommandInRepos(ctx, root, *runCommand, *workers, cfg)
+		return executeCommandInRepos(ctx, root, cmdArgs, *workers, cfg)
 	}
 
 	if *runShell != "" {
@@ -448,13 +725,7 @@ func Run(ctx context.Context, args []string) error {
 		return listAllBranches(ctx, root, *workers, cfg)
 	}
 
-	divergeSet := false
-	fs.Visit(func(f *flag.Flag) {
-		if f.Name == "diverge" || f.Name == "dv" {
-			divergeSet = true
-		}
-	})
-	if divergeSet {
+	if isFlagSet(fs, "diverge", "dv") {
 		return checkDiverge(ctx, root, *diverge, *workers, cfg)
 	}
 
@@ -462,15 +733,15 @@ func Run(ctx context.Context, args []string) error {
 		return checkTrack(ctx, root, *workers, cfg)
 	}
 
-	if *resetSoft != "" {
+	if isFlagSet(fs, "reset-soft", "rs") {
 		return syncBranch(ctx, root, *resetSoft, "soft", *workers, cfg)
 	}
 
-	if *resetHard != "" {
+	if isFlagSet(fs, "reset-hard", "rh") {
 		return syncBranch(ctx, root, *resetHard, "hard", *workers, cfg)
 	}
 
-	if *rebaseBranch != "" {
+	if isFlagSet(fs, "rebase", "rb") {
 		return syncBranch(ctx, root, *rebaseBranch, "rebase", *workers, cfg)
 	}
 
@@ -479,7 +750,7 @@ func Run(ctx context.Context, args []string) error {
 	}
 
 	if *wtCreate != "" {
-		base := "master"
+		base := ""
 		if fs.NArg() >= 1 {
 			base = fs.Arg(0)
 		}
@@ -494,7 +765,31 @@ func Run(ctx context.Context, args []string) error {
 		return worktreeOpen(ctx, root, *wtOpen, *workers, cfg)
 	}
 
+	if fs.NArg() >= 1 {
+		switch fs.Arg(0) {
+		case "status":
+			return showStatus(ctx, root, *statusOnly, *workers, cfg)
+		case "groups":
+			return listGroups(root, cfg)
+		case "history":
+			return showHistory()
+		case "logs":
+			return showLogs(fs.Args()[1:])
+		case "undo":
+			return undoRun(ctx, fs.Arg(1), *workers, cfg)
+		case "snapshot":
+			return runSnapshot(ctx, root, fs.Args()[1:], *workers, cfg)
+		case "clone":
+			return cloneWorkspace(ctx, root, manifest, *workers, cfg)
+		case "manifest":
+			return runManifest(ctx, root, fs.Args()[1:], *workers, cfg)
+		}
+	}
+
 	if fs.NArg() < 1 {
+		if cfg.manifest != nil {
+			return switchBranches(ctx, root, "", *workers, cfg)
+		}
 		fs.Usage()
 		return fmt.Errorf("branch name required")
 	}
@@ -502,8 +797,43 @@ func Run(ctx context.Context, args []string) error {
 	return switchBranches(ctx, root, fs.Arg(0), *workers, cfg)
 }
 
+func hasActionFlag(fs *flag.FlagSet) bool {
+	return isFlagSet(fs, "cmd", "c", "shell", "sh", "list", "l", "diverge", "dv", "track", "tr",
+		"reset-soft", "rs", "reset-hard", "rh", "rebase", "rb",
+		"worktree-list", "wl", "worktree-create", "wc", "worktree-remove", "wr", "worktree-open", "wo")
+}
+
+func isFlagSet(fs *flag.FlagSet, names ...string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		for _, name := range names {
+			if f.Name == name {
+				set = true
+			}
+		}
+	})
+	return set
+}
+
 func IsSilentError(err error) bool {
-	return errors.Is(err, errReposFailed)
+	return errors.Is(err, errReposFailed) || errors.Is(err, errReposCancelled)
+}
+
+const (
+	ExitFailed    = 1
+	ExitCancelled = 3
+)
+
+// ExitCode maps the error returned by Run to the process exit status: 1 for
+// failed repos or any other error, 3 when repos were cancelled.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errReposCancelled):
+		return ExitCancelled
+	}
+	return ExitFailed
 }
 
 func parseCommaSeparated(input string, defaultValue []string) []string {
@@ -0,0 +1,423 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const snapshotVersion = 1
+
+type snapshotRepo struct {
+	RelPath string            `json:"relPath"`
+	Remotes map[string]string `json:"remotes"`
+	Branch  string            `json:"branch"`
+	Head    string            `json:"head"`
+	Dirty   bool              `json:"dirty"`
+}
+
+type snapshotFile struct {
+	Version int            `json:"version"`
+	Created time.Time      `json:"created"`
+	Repos   []snapshotRepo `json:"repos"`
+}
+
+type SnapshotResult struct {
+	RelPath    string `json:"relPath"`
+	Branch     string `json:"branch"`
+	Head       string `json:"head"`
+	Success    bool   `json:"success"`
+	Skipped    bool   `json:"skipped"`
+	SkipReason string `json:"skipReason"`
+	Cancelled  bool   `json:"cancelled"`
+	Error      string `json:"error"`
+	repoTiming
+}
+
+type snapshotChange struct {
+	RelPath string `json:"relPath"`
+	Change  string `json:"change"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+func runSnapshot(ctx context.Context, root string, args []string, workers int, cfg *Config) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: gb snapshot save|restore <file> | gb snapshot diff <a> <b>")
+	}
+	switch args[0] {
+	case "save":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: gb snapshot save <file>")
+		}
+		return snapshotSave(ctx, root, args[1], workers, cfg)
+	case "restore":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: gb snapshot restore <file>")
+		}
+		return snapshotRestore(ctx, root, args[1], workers, cfg)
+	case "diff":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: gb snapshot diff <a> <b>")
+		}
+		return snapshotDiff(args[1], args[2], cfg)
+	}
+	return fmt.Errorf("unknown snapshot command %q (want save, restore or diff)", args[0])
+}
+
+func captureSnapshotRepo(r RepoInfo) (snapshotRepo, error) {
+	head, err := gitOutput(r.Path, "rev-parse", "HEAD")
+	if err != nil {
+		return snapshotRepo{}, fmt.Errorf("no commits")
+	}
+	branch, _ := gitOutput(r.Path, "branch", "--show-current")
+	snap := snapshotRepo{
+		RelPath: filepath.ToSlash(r.RelPath),
+		Remotes: make(map[string]string),
+		Branch:  branch,
+		Head:    head,
+		Dirty:   getDirtyStatus(r.Path) != "",
+	}
+	names, _ := gitOutput(r.Path, "remote")
+	for _, name := range strings.Fields(names) {
+		if url, err := gitOutput(r.Path, "remote", "get-url", name); err == nil {
+			snap.Remotes[name] = url
+		}
+	}
+	return snap, nil
+}
+
+func snapshotSave(ctx context.Context, root, file string, workers int, cfg *Config) error {
+	repos, _ := discoverRepos(root, workers, cfg, false)
+	if repos == nil {
+		return fmt.Errorf("no repos to snapshot")
+	}
+
+	type captured struct {
+		snap snapshotRepo
+		err  error
+		rel  string
+	}
+	results := runPool(ctx, repos, workers, func(_ context.Context, r RepoInfo) captured {
+		snap, err := captureSnapshotRepo(r)
+		return captured{snap: snap, err: err, rel: r.RelPath}
+	})
+
+	snap := snapshotFile{Version: snapshotVersion, Created: time.Now().UTC(), Repos: []snapshotRepo{}}
+	dirty := 0
+	for _, res := range results {
+		if res.err != nil {
+			fmt.Fprintf(cfg.infoWriter(), "%s %s\n", StyleSkipped.Render("Skipping "+res.rel+":"), res.err)
+			continue
+		}
+		if res.snap.Dirty {
+			dirty++
+		}
+		snap.Repos = append(snap.Repos, res.snap)
+	}
+	sort.Slice(snap.Repos, func(i, j int) bool { return snap.Repos[i].RelPath < snap.Repos[j].RelPath })
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintln(cfg.infoWriter(), StyleSuccess.Render(fmt.Sprintf("Saved snapshot of %d repos to %s (%d dirty)", len(snap.Repos), file, dirty)))
+	return nil
+}
+
+func readSnapshot(file string) (*snapshotFile, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var snap snapshotFile
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("%s: unsupported snapshot version %d", file, snap.Version)
+	}
+	seen := make(map[string]bool, len(snap.Repos))
+	for _, r := range snap.Repos {
+		clean := path.Clean(filepath.ToSlash(r.RelPath))
+		if r.RelPath == "" || escapesWorkspace(clean) {
+			return nil, fmt.Errorf("%s: invalid repo path %q", file, r.RelPath)
+		}
+		if seen[clean] {
+			return nil, fmt.Errorf("%s: duplicate repo path %q", file, r.RelPath)
+		}
+		seen[clean] = true
+	}
+	return &snap, nil
+}
+
+func snapshotRestore(ctx context.Context, root, file string, workers int, cfg *Config) error {
+	snap, err := readSnapshot(file)
+	if err != nil {
+		return err
+	}
+
+	out := newResultWriter(cfg.Output, "snapshot-restore")
+	entries := make(map[string]snapshotRepo, len(snap.Repos))
+	all := make([]RepoInfo, 0, len(snap.Repos))
+	for _, s := range snap.Repos {
+		rel := filepath.FromSlash(s.RelPath)
+		entries[rel] = s
+		all = append(all, RepoInfo{Path: filepath.Join(root, rel), RelPath: rel})
+	}
+	repos := cfg.filterReposForExecution(all)
+	if len(repos) == 0 {
+		_, _ = fmt.Fprintln(cfg.infoWriter(), "No repos match the specified include/exclude criteria")
+		return out.finish(false)
+	}
+
+	fmt.Fprintln(cfg.infoWriter(), StyleInfo.Render(fmt.Sprintf("Restoring %d repos from %s with %d workers...", len(repos), file, min(workers, len(repos)))))
+
+	backup := newBackupSession(newRunID(), root, "snapshot", "snapshot restore "+file)
+	progress := cfg.newProgress(repos, "Restoring snapshot", workers, nil)
+	stop := progress.start()
+
+	results := runPoolWith(ctx, repos, workers, newRunOptions[SnapshotResult](cfg, out, progress), func(ctx context.Context, r RepoInfo) SnapshotResult {
+		progress.UpdateStatus(r.RelPath, statusProcessing, "")
+		entry, err := backup.capture(r, false)
+		if err == nil {
+			err = backup.captureBranch(entry, entries[r.RelPath].Branch)
+		}
+		var res SnapshotResult
+		if err != nil {
+			res = SnapshotResult{RelPath: r.RelPath, Error: "backup failed: " + err.Error()}
+			backup.keep(entry, true)
+		} else {
+			res = restoreSnapshotRepo(ctx, r, entries[r.RelPath])
+			backup.keep(entry, !res.Success)
+		}
+		switch {
+		case res.Skipped:
+			progress.UpdateStatus(r.RelPath, statusSkipped, res.SkipReason)
+		case res.Success:
+			progress.UpdateStatus(r.RelPath, statusCompleted, "")
+		default:
+			progress.UpdateStatus(r.RelPath, statusFailed, res.Error)
+		}
+		return res
+	})
+
+	var ok, skipped, failed, cancelled int
+	for _, res := range results {
+		switch {
+		case res.Cancelled:
+			cancelled++
+		case res.Skipped:
+			skipped++
+		case res.Success:
+			ok++
+		default:
+			failed++
+		}
+	}
+
+	stop()
+	reportCI(cfg, "snapshot restore "+file, nil, results)
+
+	if err := backup.save(); err != nil {
+		fmt.Fprintln(cfg.infoWriter(), StyleFailed.Render(fmt.Sprintf("Warning: could not save run journal: %v", err)))
+	}
+
+	if out.enabled() {
+		reportTimings(cfg, results)
+		backup.report(cfg)
+		return out.finishRun(failed, cancelled)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].RelPath < results[j].RelPath })
+	for _, res := range results {
+		switch {
+		case res.Cancelled:
+		case res.Skipped:
+			fmt.Printf("%s %s\n", StyleSkipped.Render(res.RelPath+":"), res.SkipReason)
+		case !res.Success:
+			fmt.Printf("%s %s\n", StyleFailed.Render(res.RelPath+":"), res.Error)
+		}
+	}
+
+	fmt.Println("\n" + StyleBold.Render("--- Summary ---"))
+	fmt.Printf("Restored %s repos from %s, %s skipped, %s failed%s\n",
+		StyleSuccess.Render(fmt.Sprintf("%d", ok)),
+		file,
+		StyleSkipped.Render(fmt.Sprintf("%d", skipped)),
+		StyleFailed.Render(fmt.Sprintf("%d", failed)),
+		cancelledNote(cancelled))
+	reportTimings(cfg, results)
+	backup.report(cfg)
+
+	return runError(failed, cancelled)
+}
+
+func restoreSnapshotRepo(ctx context.Context, r RepoInfo, s snapshotRepo) SnapshotResult {
+	res := SnapshotResult{RelPath: r.RelPath, Branch: s.Branch, Head: s.Head}
+	if _, err := os.Stat(r.Path); err != nil {
+		res.Error = "repo not found"
+		return res
+	}
+
+	head, _ := gitOutput(r.Path, "rev-parse", "HEAD")
+	branch, _ := gitOutput(r.Path, "branch", "--show-current")
+	if head == s.Head && branch == s.Branch {
+		res.Skipped, res.SkipReason = true, "already at snapshot"
+		return res
+	}
+	if status := getDirtyStatus(r.Path); status != "" {
+		res.Skipped, res.SkipReason = true, "working tree has "+status
+		return res
+	}
+
+	if !checkCommitExists(r.Path, s.Head) {
+		remotes := make([]string, 0, len(s.Remotes))
+		for name := range s.Remotes {
+			remotes = append(remotes, name)
+		}
+		sort.Strings(remotes)
+		for _, remote := range remotes {
+			if !checkRemoteExists(r.Path, remote) {
+				continue
+			}
+			_, _, _ = executeGitCommandWithRetry(ctx, r.Path, "fetch", remote)
+			if checkCommitExists(r.Path, s.Head) {
+				break
+			}
+			_, _, _ = executeGitCommandWithRetry(ctx, r.Path, "fetch", remote, s.Head)
+			if checkCommitExists(r.Path, s.Head) {
+				break
+			}
+		}
+		if !checkCommitExists(r.Path, s.Head) {
+			res.Error = fmt.Sprintf("commit %.7s not found on any remote", s.Head)
+			return res
+		}
+	}
+
+	// switch -C would silently drop commits on the branch that the snapshot
+	// doesn't have.
+	if s.Branch != "" {
+		if tip, err := gitOutput(r.Path, "rev-parse", "--verify", "-q", "refs/heads/"+s.Branch); err == nil && tip != s.Head &&
+			newCmd("git", "-C", r.Path, "merge-base", "--is-ancestor", tip, s.Head).Run() != nil {
+			res.Skipped, res.SkipReason = true, fmt.Sprintf("branch %s has commits not in the snapshot", s.Branch)
+			return res
+		}
+	}
+
+	args := []string{"switch", "--detach", s.Head}
+	if s.Branch != "" {
+		args = []string{"switch", "-C", s.Branch, s.Head}
+	}
+	if gitOut, _, err := executeGitCommandWithRetry(ctx, r.Path, args...); err != nil {
+		res.Error = strings.TrimSpace(string(gitOut))
+		if res.Error == "" {
+			res.Error = err.Error()
+		}
+		return res
+	}
+	res.Success = true
+	return res
+}
+
+func snapshotDiff(fileA, fileB string, cfg *Config) error {
+	a, err := readSnapshot(fileA)
+	if err != nil {
+		return err
+	}
+	b, err := readSnapshot(fileB)
+	if err != nil {
+		return err
+	}
+
+	before := make(map[string]snapshotRepo, len(a.Repos))
+	for _, s := range a.Repos {
+		before[s.RelPath] = s
+	}
+	after := make(map[string]snapshotRepo, len(b.Repos))
+	for _, s := range b.Repos {
+		after[s.RelPath] = s
+	}
+
+	var changes []snapshotChange
+	for rel, s := range before {
+		if _, ok := after[rel]; !ok {
+			changes = append(changes, snapshotChange{RelPath: rel, Change: "removed", From: describeSnapshotRepo(s)})
+		}
+	}
+	for rel, t := range after {
+		s, ok := before[rel]
+		switch {
+		case !ok:
+			changes = append(changes, snapshotChange{RelPath: rel, Change: "added", To: describeSnapshotRepo(t)})
+		case s.Head != t.Head || s.Branch != t.Branch:
+			changes = append(changes, snapshotChange{RelPath: rel, Change: "moved", From: describeSnapshotRepo(s), To: describeSnapshotRepo(t)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].RelPath < changes[j].RelPath })
+
+	out := newResultWriter(cfg.Output, "snapshot-diff")
+	if out.enabled() {
+		for _, c := range changes {
+			out.add(c)
+		}
+		return out.finish(false)
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("No repos moved between the two snapshots.")
+		return nil
+	}
+	for _, c := range changes {
+		switch c.Change {
+		case "added":
+			fmt.Printf("%s %s %s\n", StyleSuccess.Render("+ "+c.RelPath), c.To, StyleDim.Render("(only in "+fileB+")"))
+		case "removed":
+			fmt.Printf("%s %s %s\n", StyleFailed.Render("- "+c.RelPath), c.From, StyleDim.Render("(only in "+fileA+")"))
+		default:
+			fmt.Printf("%s %s -> %s\n", StyleSkipped.Render("~ "+c.RelPath), c.From, c.To)
+		}
+	}
+	fmt.Printf("\n%d repos changed (%d in %s, %d in %s)\n", len(changes), len(a.Repos), fileA, len(b.Repos), fileB)
+	return nil
+}
+
+func describeSnapshotRepo(s snapshotRepo) string {
+	branch := s.Branch
+	if branch == "" {
+		branch = "detached"
+	}
+	return fmt.Sprintf("%s@%.7s", branch, s.Head)
+}
+
+func (r SnapshotResult) repoPath() string { return r.RelPath }
+
+func (r SnapshotResult) outcome() (string, string) {
+	switch {
+	case r.Cancelled:
+		return statusCancelled, ""
+	case r.Skipped:
+		return statusSkipped, r.SkipReason
+	case r.Success:
+		return statusCompleted, ""
+	}
+	return statusFailed, r.Error
+}
+
+func (SnapshotResult) cancelledResult(r RepoInfo) SnapshotResult {
+	return SnapshotResult{RelPath: r.RelPath, Cancelled: true}
+}
+
+func (c snapshotChange) repoPath() string { return c.RelPath }
+
+func (c snapshotChange) outcome() (string, string) { return statusCompleted, c.Change }
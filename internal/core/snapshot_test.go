@@ -0,0 +1,227 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotSaveAndRestore(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo1")
+	createGitRepo(t, repoDir)
+	head, _ := gitOutput(repoDir, "rev-parse", "HEAD")
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+	lockfile := filepath.Join(t.TempDir(), "snap.json")
+	if err := runQuiet(t, func() error { return snapshotSave(context.Background(), tmpDir, lockfile, 2, cfg) }); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	snap, err := readSnapshot(lockfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Repos) != 1 || snap.Repos[0].RelPath != "repo1" || snap.Repos[0].Head != head || snap.Repos[0].Branch != "main" || snap.Repos[0].Dirty {
+		t.Fatalf("unexpected snapshot: %+v", snap.Repos)
+	}
+
+	runCmd(t, repoDir, "git", "switch", "-c", "feature")
+	writeFile(t, repoDir, "new.txt", "new")
+	runCmd(t, repoDir, "git", "add", ".")
+	runCmd(t, repoDir, "git", "commit", "-m", "feature work")
+
+	if err := runQuiet(t, func() error { return snapshotRestore(context.Background(), tmpDir, lockfile, 2, cfg) }); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if got, _ := gitOutput(repoDir, "rev-parse", "HEAD"); got != head {
+		t.Errorf("expected HEAD %s, got %s", head, got)
+	}
+	if branch, _ := gitOutput(repoDir, "branch", "--show-current"); branch != "main" {
+		t.Errorf("expected branch main, got %s", branch)
+	}
+}
+
+func TestRestoreSnapshotRepoFetchesMissingCommit(t *testing.T) {
+	upstream, remoteDir := makeRepoWithRemote(t)
+	clone := filepath.Join(t.TempDir(), "app")
+	runCmd(t, filepath.Dir(clone), "git", "clone", remoteDir, clone)
+
+	writeFile(t, upstream, "later.txt", "later")
+	runCmd(t, upstream, "git", "add", ".")
+	runCmd(t, upstream, "git", "commit", "-m", "later")
+	runCmd(t, upstream, "git", "push", "origin", "main")
+	newHead, _ := gitOutput(upstream, "rev-parse", "HEAD")
+
+	s := snapshotRepo{RelPath: "app", Remotes: map[string]string{"origin": remoteDir}, Branch: "main", Head: newHead}
+	res := restoreSnapshotRepo(context.Background(), RepoInfo{Path: clone, RelPath: "app"}, s)
+	if !res.Success {
+		t.Fatalf("expected restore to succeed, got %+v", res)
+	}
+	if got, _ := gitOutput(clone, "rev-parse", "HEAD"); got != newHead {
+		t.Errorf("expected HEAD %s, got %s", newHead, got)
+	}
+}
+
+func TestRestoreSnapshotRepoSkipsDirty(t *testing.T) {
+	repoDir := t.TempDir()
+	createGitRepo(t, repoDir)
+	writeFile(t, repoDir, "README.md", "dirty")
+
+	res := restoreSnapshotRepo(context.Background(), RepoInfo{Path: repoDir, RelPath: "repo"}, snapshotRepo{Branch: "main", Head: "0000000000000000000000000000000000000000"})
+	if !res.Skipped {
+		t.Errorf("expected dirty repo to be skipped, got %+v", res)
+	}
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, repos []snapshotRepo) string {
+		data, _ := json.Marshal(snapshotFile{Version: snapshotVersion, Created: time.Now(), Repos: repos})
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	a := write("a.json", []snapshotRepo{
+		{RelPath: "api", Branch: "main", Head: "aaaaaaa1"},
+		{RelPath: "web", Branch: "main", Head: "bbbbbbb1"},
+		{RelPath: "old", Branch: "main", Head: "ccccccc1"},
+	})
+	b := write("b.json", []snapshotRepo{
+		{RelPath: "api", Branch: "main", Head: "aaaaaaa2"},
+		{RelPath: "web", Branch: "main", Head: "bbbbbbb1"},
+		{RelPath: "new", Branch: "dev", Head: "ddddddd1"},
+	})
+
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := snapshotDiff(a, b, cfg)
+	_ = w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Results []struct {
+			Result snapshotChange `json:"result"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, rec := range doc.Results {
+		got[rec.Result.RelPath] = rec.Result.Change
+	}
+	want := map[string]string{"api": "moved", "old": "removed", "new": "added"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: expected %s, got %s", k, v, got[k])
+		}
+	}
+}
+
+func TestRestoreSnapshotKeepsUnpushedCommitsAndUndoesBranchMove(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	repoDir := t.TempDir()
+	createGitRepo(t, repoDir)
+	base, _ := gitOutput(repoDir, "rev-parse", "HEAD")
+	runCmd(t, repoDir, "git", "branch", "release")
+	writeFile(t, repoDir, "next.txt", "next")
+	runCmd(t, repoDir, "git", "add", ".")
+	runCmd(t, repoDir, "git", "commit", "-m", "next")
+	next, _ := gitOutput(repoDir, "rev-parse", "HEAD")
+	repo := RepoInfo{Path: repoDir, RelPath: "repo"}
+
+	// main has a commit the snapshot doesn't: restoring it must not drop it.
+	runCmd(t, repoDir, "git", "switch", "release")
+	if res := restoreSnapshotRepo(context.Background(), repo, snapshotRepo{Branch: "main", Head: base}); !res.Skipped {
+		t.Fatalf("expected the restore to be refused, got %+v", res)
+	}
+	if tip, _ := gitOutput(repoDir, "rev-parse", "main"); tip != next {
+		t.Fatalf("expected main to keep %s, got %s", next, tip)
+	}
+
+	// release can fast-forward to the snapshot; undo moves it back.
+	session := newBackupSession(newRunID(), repoDir, "snapshot", "snapshot restore")
+	runCmd(t, repoDir, "git", "switch", "main")
+	entry, err := session.capture(repo, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.captureBranch(entry, "release"); err != nil {
+		t.Fatal(err)
+	}
+	if res := restoreSnapshotRepo(context.Background(), repo, snapshotRepo{Branch: "release", Head: next}); !res.Success {
+		t.Fatalf("expected the restore to succeed, got %+v", res)
+	}
+	session.keep(entry, false)
+	if res := restoreEntry(context.Background(), "snapshot", session.journal.Repos[0], false); res.Error != nil {
+		t.Fatalf("undo failed: %v", res.Error)
+	}
+	if tip, _ := gitOutput(repoDir, "rev-parse", "release"); tip != base {
+		t.Errorf("expected undo to move release back to %s, got %s", base, tip)
+	}
+	if branch, _ := gitOutput(repoDir, "branch", "--show-current"); branch != "main" {
+		t.Errorf("expected undo to switch back to main, got %s", branch)
+	}
+}
+
+func TestSnapshotRestoreRejectsEscapingPaths(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "outside")
+	createGitRepo(t, outside)
+	runCmd(t, outside, "git", "branch", "other")
+	head, _ := gitOutput(outside, "rev-parse", "HEAD")
+	rel, err := filepath.Rel(root, outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{"escaping", []string{filepath.ToSlash(rel)}, "invalid repo path"},
+		{"duplicate", []string{"repo1", "./repo1"}, "duplicate repo path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snap := snapshotFile{Version: snapshotVersion}
+			for _, p := range tt.paths {
+				snap.Repos = append(snap.Repos, snapshotRepo{RelPath: p, Branch: "other", Head: head})
+			}
+			data, _ := json.Marshal(snap)
+			file := filepath.Join(t.TempDir(), "snap.json")
+			if err := os.WriteFile(file, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := runQuiet(t, func() error { return snapshotRestore(context.Background(), root, file, 2, cfg) })
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+	if branch, _ := gitOutput(outside, "branch", "--show-current"); branch != "main" {
+		t.Errorf("expected the repo outside the workspace to be untouched, got branch %s", branch)
+	}
+}
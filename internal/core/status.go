@@ -0,0 +1,266 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var statusFilters = []string{"dirty", "behind", "ahead", "conflicted"}
+
+type StatusResult struct {
+	RelPath    string `json:"relPath"`
+	Branch     string `json:"branch"`
+	Detached   bool   `json:"detached"`
+	NoCommits  bool   `json:"noCommits"`
+	Staged     int    `json:"staged"`
+	Unstaged   int    `json:"unstaged"`
+	Untracked  int    `json:"untracked"`
+	Conflicted int    `json:"conflicted"`
+	Stashes    int    `json:"stashes"`
+	Upstream   string `json:"upstream"`
+	Ahead      int    `json:"ahead"`
+	Behind     int    `json:"behind"`
+	Operation  string `json:"operation"`
+	Error      string `json:"error"`
+	repoTiming
+}
+
+func (r StatusResult) dirty() bool {
+	return r.Staged+r.Unstaged+r.Untracked+r.Conflicted > 0
+}
+
+func (r StatusResult) conflicted() bool {
+	return r.Conflicted > 0 || r.Operation != ""
+}
+
+func (r StatusResult) matches(only []string) bool {
+	if len(only) == 0 {
+		return true
+	}
+	for _, f := range only {
+		switch {
+		case f == "dirty" && r.dirty(),
+			f == "behind" && r.Behind > 0,
+			f == "ahead" && r.Ahead > 0,
+			f == "conflicted" && r.conflicted():
+			return true
+		}
+	}
+	return false
+}
+
+func parseStatusFilters(only string) ([]string, error) {
+	filters := parseCommaSeparated(only, nil)
+	for _, f := range filters {
+		valid := false
+		for _, known := range statusFilters {
+			if f == known {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid --only filter %q (want %s)", f, strings.Join(statusFilters, ", "))
+		}
+	}
+	return filters, nil
+}
+
+func processSingleStatus(repo RepoInfo, remote string) StatusResult {
+	res := StatusResult{RelPath: repo.RelPath}
+
+	branch, err := getBranch(repo.Path)
+	if err != nil {
+		res.Error = "failed to get branch: " + err.Error()
+		return res
+	}
+	res.Branch = branch
+	res.NoCommits = !checkHasCommits(repo.Path)
+	if !res.NoCommits && checkDetachedHEAD(repo.Path) {
+		res.Detached = true
+		if head, err := gitOutput(repo.Path, "rev-parse", "--short", "HEAD"); err == nil {
+			res.Branch = head
+		}
+	}
+
+	counts, err := getPorcelainCounts(repo.Path)
+	if err != nil {
+		res.Error = "git status failed: " + err.Error()
+		return res
+	}
+	res.Staged, res.Unstaged, res.Untracked, res.Conflicted = counts.Staged, counts.Unstaged, counts.Untracked, counts.Conflicted
+
+	if stashes, err := gitOutput(repo.Path, "stash", "list"); err == nil && stashes != "" {
+		res.Stashes = len(strings.Split(stashes, "\n"))
+	}
+
+	if checkRebaseInProgress(repo.Path) {
+		res.Operation = "rebase"
+	} else if mid, op := checkMidOperation(repo.Path); mid {
+		res.Operation = op
+	}
+
+	if !res.NoCommits && !res.Detached {
+		dv := processSingleDiverge(repo, "", remote)
+		if dv.Error != "" {
+			res.Error = dv.Error
+			return res
+		}
+		if dv.Success {
+			res.Upstream, res.Ahead, res.Behind = dv.UpstreamRef, dv.Ahead, dv.Behind
+		}
+	}
+	return res
+}
+
+func showStatus(ctx context.Context, root, only string, workers int, cfg *Config) error {
+	filters, err := parseStatusFilters(only)
+	if err != nil {
+		return err
+	}
+
+	out := newResultWriter(cfg.Output, "status")
+	repos, total := discoverRepos(root, workers, cfg, false)
+	if repos == nil {
+		return out.finish(false)
+	}
+
+	fmt.Fprintln(cfg.infoWriter(), StyleInfo.Render(fmt.Sprintf(
+		"Found %d repos (filtered from %d discovered), collecting status with %d workers...",
+		len(repos), total, min(workers, len(repos)))))
+
+	opts := poolOptions[StatusResult]{onResult: func(res StatusResult) {
+		if res.Error != "" || res.matches(filters) {
+			out.add(res)
+		}
+	}}
+	results := runPoolWith(ctx, repos, workers, opts, func(_ context.Context, r RepoInfo) StatusResult {
+		return processSingleStatus(r, cfg.remoteFor(r.RelPath))
+	})
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+
+	if out.enabled() {
+		return out.finish(failed > 0)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].RelPath < results[j].RelPath })
+
+	const noTrackingPlaceholder = "(no tracking)"
+	shown := make([]StatusResult, 0, len(results))
+	maxPath, maxBranch, maxUpstream := 0, 0, len(noTrackingPlaceholder)
+	for _, r := range results {
+		if r.Error == "" && !r.matches(filters) {
+			continue
+		}
+		shown = append(shown, r)
+		maxPath = max(maxPath, len(r.RelPath))
+		maxBranch = max(maxBranch, len(displayStatusBranch(r)))
+		maxUpstream = max(maxUpstream, len(r.Upstream))
+	}
+
+	clean, dirty, ahead, behind, conflicted := 0, 0, 0, 0, 0
+	for _, r := range shown {
+		upstream := r.Upstream
+		if upstream == "" {
+			upstream = noTrackingPlaceholder
+		}
+		fmt.Printf("%-*s  %-*s  %-*s  %s\n",
+			maxPath, r.RelPath, maxBranch, displayStatusBranch(r), maxUpstream, upstream, buildStatusLine(r))
+
+		if r.Error != "" {
+			continue
+		}
+		if r.dirty() {
+			dirty++
+		}
+		if r.Ahead > 0 {
+			ahead++
+		}
+		if r.Behind > 0 {
+			behind++
+		}
+		if r.conflicted() {
+			conflicted++
+		}
+		if !r.dirty() && !r.conflicted() && r.Ahead == 0 && r.Behind == 0 {
+			clean++
+		}
+	}
+
+	fmt.Println("\n" + StyleBold.Render("--- Summary ---"))
+	if len(filters) > 0 {
+		fmt.Printf("Showing %d of %d repos (--only %s):\n", len(shown), len(repos), strings.Join(filters, ","))
+	} else {
+		fmt.Printf("%d repos:\n", len(repos))
+	}
+	fmt.Printf("  %s clean and in sync\n", StyleSuccess.Render(fmt.Sprintf("%d", clean)))
+	fmt.Printf("  %s dirty\n", StyleSkipped.Render(fmt.Sprintf("%d", dirty)))
+	fmt.Printf("  %s ahead, %s behind\n", StyleInfo.Render(fmt.Sprintf("%d", ahead)), StyleFailed.Render(fmt.Sprintf("%d", behind)))
+	if conflicted > 0 {
+		fmt.Printf("  %s conflicted or mid-operation\n", StyleFailed.Render(fmt.Sprintf("%d", conflicted)))
+	}
+	if failed > 0 {
+		fmt.Printf("  %s failed\n", StyleFailed.Render(fmt.Sprintf("%d", failed)))
+		return errReposFailed
+	}
+	return nil
+}
+
+func displayStatusBranch(r StatusResult) string {
+	if r.Detached {
+		return "(detached " + r.Branch + ")"
+	}
+	return r.Branch
+}
+
+func buildStatusLine(r StatusResult) string {
+	if r.Error != "" {
+		return StyleFailed.Render(r.Error)
+	}
+
+	var parts []string
+	if r.NoCommits {
+		parts = append(parts, StyleSkipped.Render("no commits"))
+	}
+	if r.Operation != "" {
+		parts = append(parts, StyleFailed.Render("⚠ "+r.Operation+" in progress"))
+	}
+	if r.Conflicted > 0 {
+		parts = append(parts, StyleFailed.Render(fmt.Sprintf("%d conflicted", r.Conflicted)))
+	}
+	if r.Staged > 0 {
+		parts = append(parts, StyleSuccess.Render(fmt.Sprintf("%d staged", r.Staged)))
+	}
+	if r.Unstaged > 0 {
+		parts = append(parts, StyleSkipped.Render(fmt.Sprintf("%d unstaged", r.Unstaged)))
+	}
+	if r.Untracked > 0 {
+		parts = append(parts, StyleDim.Render(fmt.Sprintf("%d untracked", r.Untracked)))
+	}
+	if r.Stashes > 0 {
+		parts = append(parts, StyleDim.Render(fmt.Sprintf("%d stashed", r.Stashes)))
+	}
+	if r.Ahead > 0 || r.Behind > 0 {
+		parts = append(parts, buildDivergeStatus(r.Ahead, r.Behind))
+	}
+	if len(parts) == 0 {
+		return StyleSuccess.Render("✓ clean")
+	}
+	return strings.Join(parts, "  ")
+}
+
+func (r StatusResult) repoPath() string { return r.RelPath }
+
+func (r StatusResult) outcome() (string, string) {
+	if r.Error != "" {
+		return statusFailed, r.Error
+	}
+	return statusCompleted, ""
+}
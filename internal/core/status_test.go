@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCountPorcelain(t *testing.T) {
+	out := " M a.go\nM  b.go\nMM c.go\n?? d.go\nUU e.go\nAA f.go\n"
+	got := countPorcelain(out)
+	want := porcelainCounts{Staged: 2, Unstaged: 2, Untracked: 1, Conflicted: 2}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestProcessSingleStatus(t *testing.T) {
+	repoDir, _ := makeRepoWithRemote(t)
+	runCmd(t, repoDir, "git", "branch", "-u", "origin/main")
+
+	writeFile(t, repoDir, "committed.txt", "x")
+	runCmd(t, repoDir, "git", "add", ".")
+	runCmd(t, repoDir, "git", "commit", "-m", "local")
+
+	writeFile(t, repoDir, "stashed.txt", "x")
+	runCmd(t, repoDir, "git", "stash", "push", "-u")
+
+	writeFile(t, repoDir, "staged.txt", "x")
+	runCmd(t, repoDir, "git", "add", "staged.txt")
+	writeFile(t, repoDir, "README.md", "changed")
+	writeFile(t, repoDir, "new.txt", "x")
+
+	res := processSingleStatus(RepoInfo{Path: repoDir, RelPath: "repo"}, "origin")
+	if res.Error != "" {
+		t.Fatalf("unexpected error: %s", res.Error)
+	}
+	if res.Branch != "main" || res.Upstream != "origin/main" {
+		t.Errorf("unexpected branch/upstream: %+v", res)
+	}
+	if res.Staged != 1 || res.Unstaged != 1 || res.Untracked != 1 || res.Stashes != 1 {
+		t.Errorf("unexpected counts: %+v", res)
+	}
+	if res.Ahead != 1 || res.Behind != 0 {
+		t.Errorf("expected 1 ahead, got %+v", res)
+	}
+	if !res.matches([]string{"ahead"}) || res.matches([]string{"behind", "conflicted"}) {
+		t.Errorf("unexpected filter matches for %+v", res)
+	}
+}
+
+func TestProcessSingleStatusDetachedAndMerge(t *testing.T) {
+	repoDir := t.TempDir()
+	createGitRepo(t, repoDir)
+	runCmd(t, repoDir, "git", "checkout", "--detach")
+	writeFile(t, filepath.Join(repoDir, ".git"), "MERGE_HEAD", "0000000000000000000000000000000000000000\n")
+
+	res := processSingleStatus(RepoInfo{Path: repoDir, RelPath: "repo"}, "origin")
+	if !res.Detached || res.Operation != "merge" || !res.conflicted() {
+		t.Errorf("expected detached repo mid-merge, got %+v", res)
+	}
+}
+
+func TestParseStatusFilters(t *testing.T) {
+	if got, err := parseStatusFilters("dirty, behind"); err != nil || len(got) != 2 {
+		t.Errorf("expected two filters, got %v, %v", got, err)
+	}
+	if _, err := parseStatusFilters("stale"); err == nil {
+		t.Error("expected error for unknown filter")
+	}
+}
+
+func TestShowStatusOnlyDirtyJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	createGitRepo(t, filepath.Join(tmpDir, "clean"))
+	createGitRepo(t, filepath.Join(tmpDir, "dirty"))
+	writeFile(t, filepath.Join(tmpDir, "dirty"), "new.txt", "x")
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	runErr := showStatus(context.Background(), tmpDir, "dirty", 2, cfg)
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+	outBytes, _ := io.ReadAll(r)
+
+	if runErr != nil {
+		t.Fatalf("unexpected error: %v", runErr)
+	}
+	var doc struct {
+		Kind    string `json:"kind"`
+		Results []struct {
+			Result StatusResult `json:"result"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(outBytes, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, outBytes)
+	}
+	if doc.Kind != "status" || len(doc.Results) != 1 || doc.Results[0].Result.RelPath != "dirty" {
+		t.Errorf("expected only the dirty repo, got %+v", doc)
+	}
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Red is left out so a prefix never reads as a failure.
+var streamPrefixColors = []lipgloss.Color{"12", "13", "14", "10", "11", "4", "5", "6", "2", "3"}
+
+// outputStreamer tees command output to the terminal line by line, tagging
+// each line with its repo so lines from parallel workers never interleave.
+type outputStreamer struct {
+	mu       sync.Mutex
+	w        io.Writer
+	progress *ProgressState
+}
+
+func (cfg *Config) newOutputStreamer(progress *ProgressState) *outputStreamer {
+	if !cfg.Stream {
+		return nil
+	}
+	return &outputStreamer{w: cfg.infoWriter(), progress: progress}
+}
+
+func streamPrefix(relPath string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(relPath))
+	color := streamPrefixColors[h.Sum32()%uint32(len(streamPrefixColors))]
+	return lipgloss.NewStyle().Foreground(color).Render("[" + relPath + "]")
+}
+
+func (s *outputStreamer) emit(relPath, line string) {
+	tagged := streamPrefix(relPath) + " " + line
+	if s.progress.sendOutput(tagged) {
+		return
+	}
+	s.mu.Lock()
+	_, _ = fmt.Fprintln(s.w, tagged)
+	s.mu.Unlock()
+}
+
+// tee returns the writer a repo's command output should go to and a flush
+// func that emits any trailing partial line once the command has exited.
+// Without --stream it returns logFile unchanged.
+func (s *outputStreamer) tee(relPath string, logFile io.Writer) (io.Writer, func()) {
+	if s == nil {
+		return logFile, func() {}
+	}
+	lw := &lineWriter{emit: func(line string) { s.emit(relPath, line) }}
+	return io.MultiWriter(logFile, lw), lw.flush
+}
+
+type lineWriter struct {
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	emit func(string)
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	lw.buf.Write(p)
+	for {
+		i := bytes.IndexByte(lw.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := string(lw.buf.Next(i + 1))
+		lw.emit(strings.TrimRight(line, "\r\n"))
+	}
+	return len(p), nil
+}
+
+func (lw *lineWriter) flush() {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if lw.buf.Len() > 0 {
+		lw.emit(strings.TrimRight(lw.buf.String(), "\r\n"))
+		lw.buf.Reset()
+	}
+}
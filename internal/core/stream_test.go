@@ -0,0 +1,115 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLineWriterSplitsAndFlushes(t *testing.T) {
+	var lines []string
+	lw := &lineWriter{emit: func(s string) { lines = append(lines, s) }}
+
+	_, _ = lw.Write([]byte("one\r\ntw"))
+	_, _ = lw.Write([]byte("o\nthr"))
+	if strings.Join(lines, "|") != "one|two" {
+		t.Errorf("unexpected lines before flush: %q", lines)
+	}
+	lw.flush()
+	if strings.Join(lines, "|") != "one|two|thr" {
+		t.Errorf("unexpected lines after flush: %q", lines)
+	}
+}
+
+func TestOutputStreamerKeepsLinesAtomic(t *testing.T) {
+	var buf bytes.Buffer
+	s := &outputStreamer{w: &buf, progress: &ProgressState{quiet: true}}
+
+	var wg sync.WaitGroup
+	for _, repo := range []string{"a", "b", "c"} {
+		wg.Go(func() {
+			var log bytes.Buffer
+			w, flush := s.tee(repo, &log)
+			for i := range 50 {
+				// Write each line in two halves so interleaving would show.
+				_, _ = fmt.Fprintf(w, "%s-line-", repo)
+				_, _ = fmt.Fprintf(w, "%d\n", i)
+			}
+			flush()
+			if strings.Count(log.String(), "\n") != 50 {
+				t.Errorf("log for %s missing lines", repo)
+			}
+		})
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 150 {
+		t.Fatalf("expected 150 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		tag, rest, ok := strings.Cut(line, " ")
+		if !ok || len(tag) != 3 || !strings.HasPrefix(rest, tag[1:2]+"-line-") || strings.Count(rest, "-line-") != 1 {
+			t.Errorf("interleaved line %q", line)
+		}
+	}
+}
+
+func TestNilOutputStreamerReturnsLogFile(t *testing.T) {
+	var log bytes.Buffer
+	var s *outputStreamer
+	w, flush := s.tee("repo", &log)
+	_, _ = w.Write([]byte("x\n"))
+	flush()
+	if w != &log || log.String() != "x\n" {
+		t.Error("expected writes to go straight to the log")
+	}
+}
+
+func TestModelOutputPaneScrolls(t *testing.T) {
+	m := newModel([]RepoInfo{{RelPath: "a"}}, "op", 20)
+	for i := range outputPaneLines + 5 {
+		updated, _ := m.Update(outputMsg{line: fmt.Sprintf("line %d", i)})
+		m = updated.(model)
+	}
+	if len(m.output) != outputPaneLines || m.output[0] != "line 5" {
+		t.Errorf("expected the last %d lines, got %q", outputPaneLines, m.output)
+	}
+	if !strings.Contains(m.View(), "line 14") {
+		t.Error("expected the output pane in the view")
+	}
+}
+
+func TestExecuteShellInReposStream(t *testing.T) {
+	tmpDir := t.TempDir()
+	createGitRepo(t, filepath.Join(tmpDir, "repo1"))
+	createGitRepo(t, filepath.Join(tmpDir, "repo2"))
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.Stream = true
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	runErr := executeShellInRepos(context.Background(), tmpDir, "echo hello-$GB_NAME", 2, cfg)
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+	var out bytes.Buffer
+	_, _ = out.ReadFrom(r)
+
+	if runErr != nil {
+		t.Fatalf("unexpected error: %v", runErr)
+	}
+	for _, repo := range []string{"repo1", "repo2"} {
+		if !strings.Contains(out.String(), "["+repo+"] hello-"+repo) {
+			t.Errorf("expected streamed line for %s in:\n%s", repo, out.String())
+		}
+	}
+}
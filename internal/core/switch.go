@@ -4,38 +4,59 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 )
 
 type SwitchResult struct {
-	RelPath string
-	Success bool
-	Skipped bool
-	Error   string
+	RelPath   string `json:"relPath"`
+	Success   bool   `json:"success"`
+	Skipped   bool   `json:"skipped"`
+	Cancelled bool   `json:"cancelled"`
+	Error     string `json:"error"`
+	repoTiming
 }
 
 func switchBranches(ctx context.Context, root, target string, workers int, cfg *Config) error {
+	out := newResultWriter(cfg.Output, "switch")
 	repos, total := discoverRepos(root, workers, cfg, false)
 	if repos == nil {
-		return nil
+		return out.finish(false)
 	}
 
-	fmt.Println(StyleInfo.Render(fmt.Sprintf("Found %d repos (filtered from %d discovered), switching to %s with %d workers...", len(repos), total, target, min(workers, len(repos)))))
+	displayTarget := target
+	if displayTarget == "" {
+		displayTarget = "<manifest revision>"
+	}
+
+	if cfg.DryRun {
+		return planRepos(ctx, repos, workers, cfg, "git switch "+displayTarget, func(ctx context.Context, r RepoInfo, plan *RepoPlan) repoResult {
+			return cfg.switchOne(ctx, r, target, nil, plan)
+		})
+	}
+
+	fmt.Fprintln(cfg.infoWriter(), StyleInfo.Render(fmt.Sprintf("Found %d repos (filtered from %d discovered), switching to %s with %d workers...", len(repos), total, displayTarget, min(workers, len(repos)))))
 
-	logManager, err := NewLogManager()
+	logManager, err := cfg.newLogManager(root, "git switch "+displayTarget)
 	if err != nil {
 		return fmt.Errorf("log manager: %w", err)
 	}
 
-	progress := NewProgressState(repos, "Switching branches", cfg.PageSize)
+	backup := newBackupSession(logManager.RunID(), root, "switch", "git switch "+displayTarget)
+	progress := cfg.newProgress(repos, "Switching branches", workers, logManager.expectedDurations(repos))
 	stop := progress.start()
 
-	results := runPool(ctx, repos, workers, func(_ context.Context, r RepoInfo) SwitchResult {
+	results := runPoolWith(ctx, repos, workers, newRunOptions[SwitchResult](cfg, out, progress).withLogs(logManager), func(ctx context.Context, r RepoInfo) SwitchResult {
 		progress.UpdateStatus(r.RelPath, statusProcessing, "")
 
 		logFile, _ := logManager.CreateLogFile(r.RelPath)
-		res := processSingleRepo(r, target, cfg.Remote, logFile)
+		var res SwitchResult
+		if entry, err := backup.capture(r, false); err != nil {
+			logBackupFailure(logFile, err)
+			res = SwitchResult{RelPath: r.RelPath, Error: "backup failed: " + err.Error()}
+		} else {
+			res = cfg.switchOne(ctx, r, target, logFile, nil)
+			backup.keep(entry, res.Skipped)
+		}
 		if logFile != nil {
 			_ = logFile.Close()
 		}
@@ -51,9 +72,11 @@ func switchBranches(ctx context.Context, root, target string, workers int, cfg *
 		return res
 	})
 
-	var ok, fail, skip int
+	var ok, fail, skip, cancelled int
 	for _, res := range results {
 		switch {
+		case res.Cancelled:
+			cancelled++
 		case res.Skipped:
 			skip++
 		case res.Success:
@@ -64,29 +87,41 @@ func switchBranches(ctx context.Context, root, target string, workers int, cfg *
 	}
 
 	stop()
+	logManager.finish()
+
+	reportCI(cfg, "git switch "+displayTarget, logManager, results)
+
+	if err := backup.save(); err != nil {
+		fmt.Fprintln(cfg.infoWriter(), StyleFailed.Render(fmt.Sprintf("Warning: could not save run journal: %v", err)))
+	}
+
+	if out.enabled() {
+		reportTimings(cfg, results)
+		backup.report(cfg)
+		return out.finishRun(fail, cancelled)
+	}
 
 	fmt.Println("\n" + StyleBold.Render("--- Summary ---"))
-	fmt.Printf("Switched %s repos to %s, %s skipped (branch in worktree), %s failed\n",
+	fmt.Printf("Switched %s repos to %s, %s skipped, %s failed%s\n",
 		StyleSuccess.Render(fmt.Sprintf("%d", ok)),
-		target,
+		displayTarget,
 		StyleSkipped.Render(fmt.Sprintf("%d", skip)),
-		StyleFailed.Render(fmt.Sprintf("%d", fail)))
+		StyleFailed.Render(fmt.Sprintf("%d", fail)),
+		cancelledNote(cancelled))
+	reportTimings(cfg, results)
+	backup.report(cfg)
 
 	if PromptViewLogs() {
 		DisplaySwitchLogs(logManager, results)
 	} else {
-		fmt.Printf("\nLogs are available at: %s\n", logManager.GetTempDir())
-		fmt.Println("You can review them later if needed.")
+		printLogLocation(logManager)
 	}
 
-	if fail > 0 {
-		return errReposFailed
-	}
-	return nil
+	return runError(fail, cancelled)
 }
 
 func isBranchLockedInWorktree(repoPath, targetBranch string) bool {
-	cmd := exec.Command("git", "worktree", "list", "--porcelain")
+	cmd := newCmd("git", "worktree", "list", "--porcelain")
 	cmd.Dir = repoPath
 	out, err := cmd.Output()
 	if err != nil {
@@ -107,7 +142,41 @@ func isBranchLockedInWorktree(repoPath, targetBranch string) bool {
 	return false
 }
 
+func (cfg *Config) switchOne(ctx context.Context, r RepoInfo, target string, logFile *os.File, plan *RepoPlan) SwitchResult {
+	branch := cfg.targetFor(r.RelPath, target)
+	if branch == "" {
+		return SwitchResult{RelPath: r.RelPath, Skipped: true, Error: "no revision in manifest"}
+	}
+	if target == "" && isPinnedRevision(branch) {
+		if err := checkoutPinned(ctx, r.Path, branch, cfg.remoteFor(r.RelPath), logFile, plan); err != nil {
+			return SwitchResult{RelPath: r.RelPath, Error: err.Error()}
+		}
+		return SwitchResult{RelPath: r.RelPath, Success: true}
+	}
+	return switchRepo(ctx, r, branch, cfg.remoteFor(r.RelPath), logFile, plan)
+}
+
+// checkoutPinned detaches HEAD at a manifest revision that pins a commit or
+// tag, fetching it from remote first when it isn't there locally.
+func checkoutPinned(ctx context.Context, dir, rev, remote string, logFile *os.File, plan *RepoPlan) error {
+	target := rev
+	if !checkCommitExists(dir, rev) {
+		if err := gitStep(ctx, dir, logFile, plan, "fetch", remote, rev); err != nil {
+			return fmt.Errorf("could not fetch %s from %s", rev, remote)
+		}
+		target = "FETCH_HEAD"
+	}
+	if err := gitStep(ctx, dir, logFile, plan, "switch", "--detach", target); err != nil {
+		return fmt.Errorf("could not check out %s", rev)
+	}
+	return nil
+}
+
 func processSingleRepo(repo RepoInfo, targetBranch, remote string, logFile *os.File) SwitchResult {
+	return switchRepo(context.Background(), repo, targetBranch, remote, logFile, nil)
+}
+
+func switchRepo(ctx context.Context, repo RepoInfo, targetBranch, remote string, logFile *os.File, plan *RepoPlan) SwitchResult {
 	log := func(format string, args ...any) {
 		if logFile != nil {
 			_, _ = fmt.Fprintf(logFile, format+"\n", args...)
@@ -122,7 +191,7 @@ func processSingleRepo(repo RepoInfo, targetBranch, remote string, logFile *os.F
 		return SwitchResult{RelPath: repo.RelPath, Skipped: true, Error: "branch locked in worktree"}
 	}
 
-	localCheck := exec.Command("git", "show-ref", "--verify", "--quiet", "refs/heads/"+targetBranch)
+	localCheck := newCmdContext(ctx, "git", "show-ref", "--verify", "--quiet", "refs/heads/"+targetBranch)
 	localCheck.Dir = repo.Path
 	branchExists := localCheck.Run() == nil
 
@@ -130,10 +199,10 @@ func processSingleRepo(repo RepoInfo, targetBranch, remote string, logFile *os.F
 
 	if !branchExists {
 		log("Checking remote for branch...")
-		remoteCheck := exec.Command("git", "ls-remote", "--exit-code", "--heads", remote, targetBranch)
+		remoteCheck := newCmdContext(ctx, "git", "ls-remote", "--exit-code", "--heads", remote, targetBranch)
 		remoteCheck.Dir = repo.Path
 		if remoteCheck.Run() == nil {
-			checkCmd := exec.Command("git", "rev-parse", "--is-shallow-repository")
+			checkCmd := newCmdContext(ctx, "git", "rev-parse", "--is-shallow-repository")
 			checkCmd.Dir = repo.Path
 			out, err := checkCmd.Output()
 			isShallow := err == nil && strings.TrimSpace(string(out)) == "true"
@@ -145,13 +214,7 @@ func processSingleRepo(repo RepoInfo, targetBranch, remote string, logFile *os.F
 			args = append(args, targetBranch)
 
 			log("Executing: git %s", strings.Join(args, " "))
-			fetchCmd := exec.Command("git", args...)
-			fetchCmd.Dir = repo.Path
-			if logFile != nil {
-				fetchCmd.Stdout = logFile
-				fetchCmd.Stderr = logFile
-			}
-			if err := fetchCmd.Run(); err != nil {
+			if err := gitStep(ctx, repo.Path, logFile, plan, args...); err != nil {
 				log("Fetch failed: %v", err)
 				return SwitchResult{RelPath: repo.RelPath, Success: false, Error: "fetch failed"}
 			}
@@ -163,26 +226,14 @@ func processSingleRepo(repo RepoInfo, targetBranch, remote string, logFile *os.F
 	}
 
 	log("Executing: git switch %s", targetBranch)
-	switchCmd := exec.Command("git", "switch", targetBranch)
-	switchCmd.Dir = repo.Path
-	if logFile != nil {
-		switchCmd.Stdout = logFile
-		switchCmd.Stderr = logFile
-	}
-	if err := switchCmd.Run(); err == nil {
+	if err := gitStep(ctx, repo.Path, logFile, plan, "switch", targetBranch); err == nil {
 		log("Switch completed successfully")
 		return SwitchResult{RelPath: repo.RelPath, Success: true}
 	}
 
 	log("Switch failed, trying to create tracking branch...")
 	log("Executing: git switch -c %s --track %s/%s", targetBranch, remote, targetBranch)
-	trackCmd := exec.Command("git", "switch", "-c", targetBranch, "--track", remote+"/"+targetBranch)
-	trackCmd.Dir = repo.Path
-	if logFile != nil {
-		trackCmd.Stdout = logFile
-		trackCmd.Stderr = logFile
-	}
-	if err := trackCmd.Run(); err == nil {
+	if err := gitStep(ctx, repo.Path, logFile, plan, "switch", "-c", targetBranch, "--track", remote+"/"+targetBranch); err == nil {
 		log("Created tracking branch successfully")
 		return SwitchResult{RelPath: repo.RelPath, Success: true}
 	}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+var templateVarNames = []string{"RelPath", "Name", "Path", "Branch", "Upstream", "Remote", "HeadSHA"}
+
+type templateVars struct {
+	RelPath  string
+	Name     string
+	Path     string
+	Branch   string
+	Upstream string
+	Remote   string
+	HeadSHA  string
+}
+
+func (cfg *Config) templateVars(r RepoInfo) templateVars {
+	v := templateVars{
+		RelPath: filepath.ToSlash(r.RelPath),
+		Name:    filepath.Base(r.Path),
+		Path:    r.Path,
+		Remote:  cfg.remoteFor(r.RelPath),
+	}
+	v.Branch, _ = gitOutput(r.Path, "branch", "--show-current")
+	v.Upstream, _ = getTrackingRef(r.Path)
+	v.HeadSHA, _ = gitOutput(r.Path, "rev-parse", "HEAD")
+	return v
+}
+
+func (v templateVars) env() []string {
+	return []string{
+		"GB_REL_PATH=" + v.RelPath,
+		"GB_NAME=" + v.Name,
+		"GB_PATH=" + v.Path,
+		"GB_BRANCH=" + v.Branch,
+		"GB_UPSTREAM=" + v.Upstream,
+		"GB_REMOTE=" + v.Remote,
+		"GB_HEAD_SHA=" + v.HeadSHA,
+	}
+}
+
+// shellQuoted quotes every value that sh would split or expand, for
+// rendering an -sh command. cmd.exe has no quoting that survives every
+// value, so there the GB_* variables are the safe way in.
+func (v templateVars) shellQuoted() templateVars {
+	if runtime.GOOS == "windows" {
+		return v
+	}
+	quote := func(s string) string { return quoteCommandLine([]string{s}) }
+	v.RelPath, v.Name, v.Path = quote(v.RelPath), quote(v.Name), quote(v.Path)
+	v.Branch, v.Upstream, v.Remote, v.HeadSHA = quote(v.Branch), quote(v.Upstream), quote(v.Remote), quote(v.HeadSHA)
+	return v
+}
+
+// commandTemplate holds a command's words, parsed as text/template where
+// they contain {{...}}, so each repo can render its own argv.
+type commandTemplate struct {
+	words     []string
+	tmpls     []*template.Template
+	templated bool
+}
+
+func parseCommandTemplate(words ...string) (*commandTemplate, error) {
+	c := &commandTemplate{words: words, tmpls: make([]*template.Template, len(words))}
+	for i, w := range words {
+		if !strings.Contains(w, "{{") {
+			continue
+		}
+		t, err := template.New("cmd").Option("missingkey=error").Parse(w)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template %q: %w", w, err)
+		}
+		if err := t.Execute(io.Discard, templateVars{}); err != nil {
+			return nil, fmt.Errorf("invalid template %q: %w (available: %s)", w, err, strings.Join(templateVarNames, ", "))
+		}
+		c.tmpls[i] = t
+		c.templated = true
+	}
+	return c, nil
+}
+
+func (c *commandTemplate) render(v templateVars) ([]string, error) {
+	if !c.templated {
+		return c.words, nil
+	}
+	out := make([]string, len(c.words))
+	for i, w := range c.words {
+		if c.tmpls[i] == nil {
+			out[i] = w
+			continue
+		}
+		var b strings.Builder
+		if err := c.tmpls[i].Execute(&b, v); err != nil {
+			return nil, err
+		}
+		out[i] = b.String()
+	}
+	return out, nil
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseCommandTemplateUnknownVariable(t *testing.T) {
+	_, err := parseCommandTemplate("push", "{{.Remote}}", "{{.Brnach}}")
+	if err == nil || !strings.Contains(err.Error(), "Brnach") {
+		t.Fatalf("expected unknown variable error, got %v", err)
+	}
+	if _, err := parseCommandTemplate("{{.Name"); err == nil {
+		t.Error("expected parse error for unclosed action")
+	}
+}
+
+func TestCommandTemplateRender(t *testing.T) {
+	tmpl, err := parseCommandTemplate("push", "{{.Remote}}", "{{.Branch}}:refs/heads/{{.Branch}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := tmpl.render(templateVars{Remote: "upstream", Branch: "feat x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"push", "upstream", "feat x:refs/heads/feat x"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTemplateVars(t *testing.T) {
+	repoDir, _ := makeRepoWithRemote(t)
+	runCmd(t, repoDir, "git", "branch", "-u", "origin/main")
+	cfg := mustConfig(t, nil, nil, nil, nil, 20, false, "origin")
+
+	v := cfg.templateVars(RepoInfo{Path: repoDir, RelPath: filepath.Join("group", "repo")})
+	head := strings.TrimSpace(string(runCmdOutput(t, repoDir, "git", "rev-parse", "HEAD")))
+	if v.RelPath != "group/repo" || v.Name != filepath.Base(repoDir) || v.Branch != "main" ||
+		v.Upstream != "origin/main" || v.Remote != "origin" || v.HeadSHA != head {
+		t.Errorf("unexpected vars: %+v", v)
+	}
+}
+
+func TestExecuteShellInReposQuotesTemplateValues(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	tmpDir := t.TempDir()
+	name := "it's $HOME; touch pwned"
+	createGitRepo(t, filepath.Join(tmpDir, name))
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+	var runErr error
+	_ = runQuiet(t, func() error {
+		runErr = executeShellInRepos(context.Background(), tmpDir, `test {{.Name}} = "$GB_NAME"`, 2, cfg)
+		return runErr
+	})
+	if runErr != nil {
+		t.Errorf("expected the quoted name to match $GB_NAME, got %v", runErr)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, name, "pwned")); err == nil {
+		t.Error("expected the repo name not to run as a command")
+	}
+}
+
+func TestExecuteShellInReposExportsGitStateToScripts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo1")
+	createGitRepo(t, repoDir)
+	head := strings.TrimSpace(string(runCmdOutput(t, repoDir, "git", "rev-parse", "HEAD")))
+	script := filepath.Join(t.TempDir(), "check.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ntest \"$GB_BRANCH\" = main && test \"$GB_HEAD_SHA\" = "+head+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+	if err := runQuiet(t, func() error { return executeShellInRepos(context.Background(), tmpDir, script, 2, cfg) }); err != nil {
+		t.Errorf("expected the script to see GB_BRANCH and GB_HEAD_SHA, got %v", err)
+	}
+}
+
+func TestExecuteShellInReposTemplateAndEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	tmpDir := t.TempDir()
+	createGitRepo(t, filepath.Join(tmpDir, "repo1"))
+
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+	cfg.Output = outputJSON
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	runErr := executeShellInRepos(context.Background(), tmpDir, `echo "{{.Name}}@{{.Branch}} $GB_REL_PATH $GB_REMOTE"`, 2, cfg)
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+	outBytes, _ := io.ReadAll(r)
+
+	if runErr != nil {
+		t.Fatalf("unexpected error: %v", runErr)
+	}
+	var doc struct {
+		Results []struct {
+			Result struct {
+				Output string `json:"output"`
+			} `json:"result"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(outBytes, &doc); err != nil || len(doc.Results) != 1 {
+		t.Fatalf("invalid JSON: %v\n%s", err, outBytes)
+	}
+	if got := strings.TrimSpace(doc.Results[0].Result.Output); got != "repo1@main repo1 origin" {
+		t.Errorf("unexpected output %q", got)
+	}
+}
+
+func TestExecuteCommandInReposRejectsUnknownVariable(t *testing.T) {
+	tmpDir := t.TempDir()
+	createGitRepo(t, filepath.Join(tmpDir, "repo1"))
+	cfg := mustConfig(t, defaultExcludeDirs, nil, nil, nil, 20, false, "origin")
+
+	err := executeCommandInRepos(context.Background(), tmpDir, []string{"tag", "{{.Version}}"}, 2, cfg)
+	if err == nil || !strings.Contains(err.Error(), "Version") {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if tags := strings.TrimSpace(string(runCmdOutput(t, filepath.Join(tmpDir, "repo1"), "git", "tag"))); tags != "" {
+		t.Errorf("expected no tags, got %q", tags)
+	}
+}
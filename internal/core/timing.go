@@ -0,0 +1,292 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	timingsFile     = "timings.json"
+	timingRetention = 100
+	slowestShown    = 3
+)
+
+// repoTiming is embedded in every result type; the pool fills it in with
+// how long the repo took, retries included.
+type repoTiming struct {
+	DurationMs int64 `json:"durationMs"`
+}
+
+func (t repoTiming) duration() time.Duration {
+	return time.Duration(t.DurationMs) * time.Millisecond
+}
+
+func (t *repoTiming) setDuration(d time.Duration) {
+	t.DurationMs = d.Milliseconds()
+}
+
+type timedResult interface {
+	repoResult
+	duration() time.Duration
+}
+
+type retriedResult interface {
+	retryCount() int
+}
+
+// timingRecord holds the latest duration of each repo for one command in one
+// workspace, for estimating later runs of it.
+type timingRecord struct {
+	Root    string           `json:"root"`
+	Command string           `json:"command"`
+	Updated time.Time        `json:"updated"`
+	Repos   map[string]int64 `json:"repos"`
+}
+
+func timingsPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, timingsFile), nil
+}
+
+func loadTimings() ([]timingRecord, error) {
+	path, err := timingsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []timingRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// loadRepoTimings returns the durations recorded for command in root, or nil.
+func loadRepoTimings(root, command string) map[string]time.Duration {
+	records, err := loadTimings()
+	if err != nil {
+		return nil
+	}
+	for _, rec := range records {
+		if rec.Root == root && rec.Command == command {
+			durations := make(map[string]time.Duration, len(rec.Repos))
+			for relPath, ms := range rec.Repos {
+				durations[relPath] = time.Duration(ms) * time.Millisecond
+			}
+			return durations
+		}
+	}
+	return nil
+}
+
+// saveRepoTimings merges a run's durations into the stored ones, keeping
+// older entries for repos that didn't run this time.
+func saveRepoTimings(root, command string, durations map[string]time.Duration) error {
+	if root == "" || command == "" || len(durations) == 0 {
+		return nil
+	}
+	path, err := timingsPath()
+	if err != nil {
+		return err
+	}
+	records, err := loadTimings()
+	if err != nil {
+		records = nil
+	}
+
+	var rec *timingRecord
+	for i := range records {
+		if records[i].Root == root && records[i].Command == command {
+			rec = &records[i]
+			break
+		}
+	}
+	if rec == nil {
+		records = append(records, timingRecord{Root: root, Command: command, Repos: make(map[string]int64)})
+		rec = &records[len(records)-1]
+	}
+	rec.Updated = time.Now()
+	for relPath, d := range durations {
+		rec.Repos[relPath] = d.Milliseconds()
+	}
+
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Updated.After(records[j].Updated) })
+	if len(records) > timingRetention {
+		records = records[:timingRetention]
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Concurrent runs share the file: replace it in one rename so none of
+	// them ever reads it half written.
+	tmp, err := os.CreateTemp(filepath.Dir(path), timingsFile+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// byDuration returns the results that took any time, slowest first.
+func byDuration[R timedResult](results []R) []R {
+	sorted := make([]R, 0, len(results))
+	for _, res := range results {
+		if res.duration() > 0 {
+			sorted = append(sorted, res)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].duration() > sorted[j].duration() })
+	return sorted
+}
+
+// reportTimings prints every repo's timing with --timings, and otherwise
+// names the slowest repos under the summary.
+func reportTimings[R timedResult](cfg *Config, results []R) {
+	if cfg.Timings {
+		printTimings(cfg.infoWriter(), results)
+		return
+	}
+	if cfg.Output != "" {
+		return
+	}
+	sorted := byDuration(results)
+	if len(sorted) < 2 {
+		return
+	}
+	var parts []string
+	for _, res := range sorted[:min(slowestShown, len(sorted))] {
+		parts = append(parts, fmt.Sprintf("%s %s", res.repoPath(), StyleDim.Render(formatDuration(res.duration()))))
+	}
+	fmt.Printf("Slowest: %s\n", strings.Join(parts, ", "))
+}
+
+func printTimings[R timedResult](w io.Writer, results []R) {
+	sorted := byDuration(results)
+	if len(sorted) == 0 {
+		return
+	}
+	retries := false
+	width := len("REPO")
+	var total time.Duration
+	for _, res := range sorted {
+		width = max(width, len(res.repoPath()))
+		total += res.duration()
+		if r, ok := any(res).(retriedResult); ok && r.retryCount() > 0 {
+			retries = true
+		}
+	}
+
+	header := fmt.Sprintf("%-9s %-10s %-*s", "DURATION", "STATUS", width, "REPO")
+	if retries {
+		header += "  RETRIES"
+	}
+	_, _ = fmt.Fprintln(w, "\n"+StyleBold.Render(header))
+	for _, res := range sorted {
+		state, _ := res.outcome()
+		line := fmt.Sprintf("%-9s %-10s %-*s", formatDuration(res.duration()), state, width, res.repoPath())
+		if r, ok := any(res).(retriedResult); ok && r.retryCount() > 0 {
+			line += fmt.Sprintf("  %d", r.retryCount())
+		}
+		line = strings.TrimRight(line, " ")
+		if state == statusFailed {
+			line = StyleFailed.Render(line)
+		}
+		_, _ = fmt.Fprintln(w, line)
+	}
+	_, _ = fmt.Fprintf(w, "%s across %d repos\n", StyleDim.Render(formatDuration(total)), len(sorted))
+}
+
+// estimateDurations expects each repo to take as long as it did last time,
+// and a repo without history the median of those that have one. It is nil
+// without any history.
+func estimateDurations(repos []RepoInfo, history map[string]time.Duration) map[string]time.Duration {
+	if len(history) == 0 {
+		return nil
+	}
+	known := make([]time.Duration, 0, len(history))
+	for _, d := range history {
+		known = append(known, d)
+	}
+	slices.Sort(known)
+	median := known[len(known)/2]
+	if len(known)%2 == 0 {
+		median = (known[len(known)/2-1] + median) / 2
+	}
+
+	expected := make(map[string]time.Duration, len(repos))
+	for _, r := range repos {
+		if d, ok := history[r.RelPath]; ok {
+			expected[r.RelPath] = d
+		} else {
+			expected[r.RelPath] = median
+		}
+	}
+	return expected
+}
+
+// longestFirst orders repos by expected duration, so the slowest start
+// first instead of holding up the end of the run.
+func longestFirst(repos []RepoInfo, expected map[string]time.Duration) []RepoInfo {
+	sorted := append([]RepoInfo{}, repos...)
+	sort.SliceStable(sorted, func(i, j int) bool { return expected[sorted[i].RelPath] > expected[sorted[j].RelPath] })
+	return sorted
+}
+
+// estimateRemaining simulates the rest of the run on the given number of
+// workers: running repos take what is left of their expected time, and
+// waiting ones start longest first on whichever worker frees up first. It
+// reports false when nothing is running, as before the start or while the
+// run is paused.
+func estimateRemaining(workers int, running, waiting []time.Duration) (time.Duration, bool) {
+	if len(running) == 0 {
+		return 0, false
+	}
+	slots := make([]time.Duration, max(workers, len(running)))
+	copy(slots, running)
+	waiting = append([]time.Duration{}, waiting...)
+	sort.Slice(waiting, func(i, j int) bool { return waiting[i] > waiting[j] })
+	for _, d := range waiting {
+		next := 0
+		for i := range slots {
+			if slots[i] < slots[next] {
+				next = i
+			}
+		}
+		slots[next] += d
+	}
+	return slices.Max(slots), true
+}
+
+func formatETA(d time.Duration) string {
+	if d < time.Second {
+		return "ETA <1s"
+	}
+	return "ETA " + d.Round(time.Second).String()
+}
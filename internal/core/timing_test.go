@@ -0,0 +1,140 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunPoolRecordsDurations(t *testing.T) {
+	results := runPool(context.Background(), testRepos(3), 3, func(_ context.Context, r RepoInfo) CommandResult {
+		if r.RelPath == "repo01" {
+			time.Sleep(30 * time.Millisecond)
+		}
+		return CommandResult{RelPath: r.RelPath}
+	})
+	for _, res := range results {
+		if res.RelPath == "repo01" && res.duration() < 30*time.Millisecond {
+			t.Errorf("expected repo01 to take at least 30ms, got %s", res.duration())
+		}
+	}
+	if sorted := byDuration(results); len(sorted) == 0 || sorted[0].RelPath != "repo01" {
+		t.Errorf("expected repo01 to be the slowest, got %v", sorted)
+	}
+}
+
+func TestPrintTimings(t *testing.T) {
+	results := []CommandResult{
+		{RelPath: "api", repoTiming: repoTiming{DurationMs: 1500}},
+		{RelPath: "web", Error: errors.New("timeout"), Retries: 2, repoTiming: repoTiming{DurationMs: 62000}},
+		{RelPath: "cli", Cancelled: true},
+	}
+	var buf bytes.Buffer
+	printTimings(&buf, results)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[0], "RETRIES") {
+		t.Fatalf("expected a header, two repos and a total, got:\n%s", buf.String())
+	}
+	if !strings.HasPrefix(lines[1], "1m2s      failed     web   2") || !strings.HasPrefix(lines[2], "1.5s      completed  api") {
+		t.Errorf("expected the slowest repo first with its retries, got:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[3], "1m3.5s") || !strings.Contains(lines[3], "across 2 repos") {
+		t.Errorf("unexpected total line %q", lines[3])
+	}
+}
+
+func TestRepoTimingsPersist(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	if err := saveRepoTimings("/ws", "git fetch", map[string]time.Duration{"api": time.Second, "web": 2 * time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveRepoTimings("/ws", "git fetch", map[string]time.Duration{"api": 3 * time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveRepoTimings("/ws", "git status", map[string]time.Duration{"api": time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := loadRepoTimings("/ws", "git fetch")
+	if got["api"] != 3*time.Second || got["web"] != 2*time.Second {
+		t.Errorf("expected the latest duration per repo, got %v", got)
+	}
+	if loadRepoTimings("/other", "git fetch") != nil {
+		t.Error("expected no timings for another workspace")
+	}
+}
+
+func TestEstimateDurationsUsesMedianForNewRepos(t *testing.T) {
+	history := map[string]time.Duration{"api": 10 * time.Second, "web": 2 * time.Second, "cli": 4 * time.Second, "gone": time.Second}
+	expected := estimateDurations([]RepoInfo{{RelPath: "api"}, {RelPath: "new"}}, history)
+	if expected["api"] != 10*time.Second || expected["new"] != 3*time.Second {
+		t.Errorf("expected history for api and the 3s median for new, got %v", expected)
+	}
+	if estimateDurations([]RepoInfo{{RelPath: "api"}}, nil) != nil {
+		t.Error("expected no estimates without history")
+	}
+}
+
+func TestRunPoolStartsLongestFirst(t *testing.T) {
+	opts := poolOptions[CommandResult]{expected: map[string]time.Duration{"repo00": time.Second, "repo01": time.Minute, "repo02": 10 * time.Second}}
+	var order []string
+	runPoolWith(context.Background(), testRepos(3), 1, opts, func(_ context.Context, r RepoInfo) CommandResult {
+		order = append(order, r.RelPath)
+		return CommandResult{RelPath: r.RelPath}
+	})
+	if got := strings.Join(order, ","); got != "repo01,repo02,repo00" {
+		t.Errorf("expected the longest repos to start first, got %s", got)
+	}
+}
+
+func TestEstimateRemaining(t *testing.T) {
+	// Two workers: the 5s repo follows the 1s one, the 3s repo the 2s one.
+	eta, ok := estimateRemaining(2, []time.Duration{time.Second, 2 * time.Second}, []time.Duration{3 * time.Second, 5 * time.Second})
+	if !ok || eta != 6*time.Second {
+		t.Errorf("expected a 6s ETA, got %s (%v)", eta, ok)
+	}
+	// Idle workers pick up waiting repos straight away.
+	if eta, _ := estimateRemaining(3, []time.Duration{time.Second}, []time.Duration{3 * time.Second, 5 * time.Second}); eta != 5*time.Second {
+		t.Errorf("expected a 5s ETA with idle workers, got %s", eta)
+	}
+	if _, ok := estimateRemaining(2, nil, []time.Duration{time.Second}); ok {
+		t.Error("expected no ETA before anything is running")
+	}
+
+	p, _, clock := newTestPlainProgress(true)
+	p.expected = map[string]time.Duration{"api": 10 * time.Second, "web": 4 * time.Second, "cli": 4 * time.Second}
+	p.workers = 2
+	p.update("api", statusProcessing, "")
+	*clock = clock.Add(2 * time.Second)
+	if got := p.heartbeatLine(); !strings.HasSuffix(got, ", ETA 8s") {
+		t.Errorf("expected the ETA in the heartbeat, got %q", got)
+	}
+}
+
+func TestConcurrentTimingSavesKeepFileValid(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = saveRepoTimings("/ws", fmt.Sprintf("git cmd%d", i), map[string]time.Duration{"api": time.Second})
+		}()
+	}
+	wg.Wait()
+
+	if _, err := loadTimings(); err != nil {
+		t.Fatalf("expected a valid timings file, got %v", err)
+	}
+	path, _ := timingsPath()
+	if leftovers, _ := filepath.Glob(path + ".*"); len(leftovers) != 0 {
+		t.Errorf("expected no temporary files, got %v", leftovers)
+	}
+}
@@ -3,16 +3,16 @@ package core
 import (
 	"context"
 	"fmt"
-	"os/exec"
 	"sort"
 	"strings"
 )
 
 type TrackResult struct {
-	RelPath  string
-	Branch   string
-	Upstream string
-	Error    string
+	RelPath  string `json:"relPath"`
+	Branch   string `json:"branch"`
+	Upstream string `json:"upstream"`
+	Error    string `json:"error"`
+	repoTiming
 }
 
 func processSingleTrack(repo RepoInfo) TrackResult {
@@ -21,7 +21,7 @@ func processSingleTrack(repo RepoInfo) TrackResult {
 		return TrackResult{RelPath: repo.RelPath, Error: "failed to get branch: " + err.Error()}
 	}
 
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}")
+	cmd := newCmd("git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}")
 	cmd.Dir = repo.Path
 	out, err := cmd.Output()
 	if err != nil {
@@ -37,19 +37,24 @@ func processSingleTrack(repo RepoInfo) TrackResult {
 }
 
 func checkTrack(ctx context.Context, root string, workers int, cfg *Config) error {
+	out := newResultWriter(cfg.Output, "track")
 	repos, total := discoverRepos(root, workers, cfg, false)
 	if repos == nil {
-		return nil
+		return out.finish(false)
 	}
 
-	fmt.Println(StyleInfo.Render(fmt.Sprintf(
+	fmt.Fprintln(cfg.infoWriter(), StyleInfo.Render(fmt.Sprintf(
 		"Found %d repos (filtered from %d discovered), checking upstream tracking with %d workers...",
 		len(repos), total, min(workers, len(repos)))))
 
-	results := runPool(ctx, repos, workers, func(_ context.Context, r RepoInfo) TrackResult {
+	results := runPoolWith(ctx, repos, workers, newPoolOptions[TrackResult](out), func(_ context.Context, r RepoInfo) TrackResult {
 		return processSingleTrack(r)
 	})
 
+	if out.enabled() {
+		return out.finish(false)
+	}
+
 	sort.Slice(results, func(i, j int) bool { return results[i].RelPath < results[j].RelPath })
 
 	maxPath, maxBranch := 0, 0
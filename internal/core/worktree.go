@@ -17,35 +17,68 @@ func worktreePath(repoPath, branch string) string {
 	return filepath.Join(filepath.Dir(repoPath), filepath.Base(repoPath)+"-"+suffix)
 }
 
+type WorktreeEntry struct {
+	Branch string `json:"branch"`
+	Path   string `json:"path"`
+}
+
+type WorktreeListResult struct {
+	RelPath   string          `json:"relPath"`
+	Worktrees []WorktreeEntry `json:"worktrees"`
+	Error     string          `json:"error"`
+	output    string
+	repoTiming
+}
+
+type WorktreePathResult struct {
+	RelPath string `json:"relPath"`
+	Path    string `json:"path"`
+	Exists  bool   `json:"exists"`
+	repoTiming
+}
+
 func worktreeListAll(ctx context.Context, root string, workers int, cfg *Config) error {
+	out := newResultWriter(cfg.Output, "worktree-list")
 	repos, _ := discoverRepos(root, workers, cfg, true)
 	if repos == nil {
-		return nil
+		return out.finish(false)
 	}
 
-	fmt.Println(StyleInfo.Render(fmt.Sprintf("Listing worktrees in %d repos...", len(repos))))
-
-	type wtResult struct {
-		relPath string
-		output  string
-		err     error
-	}
+	fmt.Fprintln(cfg.infoWriter(), StyleInfo.Render(fmt.Sprintf("Listing worktrees in %d repos...", len(repos))))
 
-	results := runPool(ctx, repos, workers, func(ctx context.Context, r RepoInfo) wtResult {
-		out, _, err := executeGitCommandWithRetry(ctx, r.Path, "worktree", "list")
-		return wtResult{relPath: r.RelPath, output: string(out), err: err}
+	results := runPoolWith(ctx, repos, workers, newPoolOptions[WorktreeListResult](out), func(ctx context.Context, r RepoInfo) WorktreeListResult {
+		args := []string{"worktree", "list"}
+		if out.enabled() {
+			args = append(args, "--porcelain")
+		}
+		gitOut, _, err := executeGitCommandWithRetry(ctx, r.Path, args...)
+		if err != nil {
+			return WorktreeListResult{RelPath: r.RelPath, Error: err.Error()}
+		}
+		res := WorktreeListResult{RelPath: r.RelPath, output: string(gitOut)}
+		if out.enabled() {
+			res.Worktrees = []WorktreeEntry{}
+			for _, pair := range parseWorktreeList(string(gitOut)) {
+				res.Worktrees = append(res.Worktrees, WorktreeEntry{Branch: pair[0], Path: pair[1]})
+			}
+		}
+		return res
 	})
 
-	entries := make([]wtResult, 0, len(results))
+	if out.enabled() {
+		return out.finish(false)
+	}
+
+	entries := make([]WorktreeListResult, 0, len(results))
 	for _, res := range results {
-		if res.err == nil {
+		if res.Error == "" {
 			entries = append(entries, res)
 		}
 	}
-	sort.Slice(entries, func(i, j int) bool { return entries[i].relPath < entries[j].relPath })
+	sort.Slice(entries, func(i, j int) bool { return entries[i].RelPath < entries[j].RelPath })
 
 	for _, e := range entries {
-		fmt.Printf("%s %s\n", StyleBold.Render("Repo:"), StyleSuccess.Render(e.relPath))
+		fmt.Printf("%s %s\n", StyleBold.Render("Repo:"), StyleSuccess.Render(e.RelPath))
 		fmt.Println(StyleDim.Render("-----------------"))
 		fmt.Print(e.output)
 		fmt.Println(StyleDim.Render("================="))
@@ -54,22 +87,33 @@ func worktreeListAll(ctx context.Context, root string, workers int, cfg *Config)
 }
 
 func worktreeCreate(ctx context.Context, root, branch, base string, workers int, cfg *Config) error {
+	out := newResultWriter(cfg.Output, "worktree-create")
 	repos, _ := discoverRepos(root, workers, cfg, true)
 	if repos == nil {
-		return nil
+		return out.finish(false)
+	}
+
+	if cfg.DryRun {
+		return planRepos(ctx, repos, workers, cfg, "git worktree add "+branch, func(ctx context.Context, r RepoInfo, plan *RepoPlan) repoResult {
+			return createWorktree(ctx, r, branch, base, cfg, io.Discard, &ProgressState{quiet: true}, plan)
+		})
 	}
 
-	fmt.Println(StyleInfo.Render(fmt.Sprintf("Creating worktrees for '%s' (base: %s) in %d repos with %d workers...", branch, base, len(repos), min(workers, len(repos)))))
+	displayBase := base
+	if displayBase == "" {
+		displayBase = "per-repo default"
+	}
+	fmt.Fprintln(cfg.infoWriter(), StyleInfo.Render(fmt.Sprintf("Creating worktrees for '%s' (base: %s) in %d repos with %d workers...", branch, displayBase, len(repos), min(workers, len(repos)))))
 
-	logManager, err := NewLogManager()
+	logManager, err := cfg.newLogManager(root, "git worktree add "+branch)
 	if err != nil {
 		return fmt.Errorf("log manager: %w", err)
 	}
 
-	progress := NewProgressState(repos, fmt.Sprintf("Creating worktree '%s'", branch), cfg.PageSize)
+	progress := cfg.newProgress(repos, fmt.Sprintf("Creating worktree '%s'", branch), workers, logManager.expectedDurations(repos))
 	stop := progress.start()
 
-	results := runPool(ctx, repos, workers, func(ctx context.Context, r RepoInfo) CommandResult {
+	results := runPoolWith(ctx, repos, workers, newRunOptions[CommandResult](cfg, out, progress).withLogs(logManager), func(ctx context.Context, r RepoInfo) CommandResult {
 		progress.UpdateStatus(r.RelPath, statusProcessing, "")
 
 		logFile, _ := logManager.CreateLogFile(r.RelPath)
@@ -79,56 +123,14 @@ func worktreeCreate(ctx context.Context, root, branch, base string, workers int,
 			defer func() { _ = logFile.Close() }()
 		}
 
-		wtPath := worktreePath(r.Path, branch)
-
-		if _, statErr := os.Stat(wtPath); statErr == nil {
-			_, _ = fmt.Fprintf(out, "worktree already exists at %s\n", wtPath)
-			progress.UpdateStatus(r.RelPath, statusSkipped, "worktree already exists")
-			return CommandResult{RelPath: r.RelPath, Skipped: true}
-		}
-
-		if _, _, refErr := executeGitCommandWithRetry(ctx, r.Path, "show-ref", "--verify", "--quiet", "refs/heads/"+branch); refErr != nil {
-			_, _ = fmt.Fprintf(out, "Creating branch '%s' from '%s'...\n", branch, base)
-			gitOut, _, bErr := executeGitCommandWithRetry(ctx, r.Path, "branch", branch, base)
-			if bErr != nil && !strings.Contains(base, "/") {
-				gitOut, _, bErr = executeGitCommandWithRetry(ctx, r.Path, "branch", branch, cfg.Remote+"/"+base)
-			}
-			if bErr != nil {
-				_, _ = fmt.Fprintf(out, "%s", gitOut)
-				_, _ = fmt.Fprintf(out, "Failed to create branch: %v\n", bErr)
-				progress.UpdateStatus(r.RelPath, statusFailed, fmt.Sprintf("base '%s' not found", base))
-				return CommandResult{RelPath: r.RelPath, Error: bErr}
-			}
-		}
-
-		if mkErr := os.MkdirAll(filepath.Dir(wtPath), 0o755); mkErr != nil {
-			progress.UpdateStatus(r.RelPath, statusFailed, "mkdir failed")
-			return CommandResult{RelPath: r.RelPath, Error: mkErr}
-		}
-
-		gitOut, _, addErr := executeGitCommandWithRetry(ctx, r.Path, "worktree", "add", wtPath, branch)
-		if addErr != nil {
-			_, _ = fmt.Fprintf(out, "%s", gitOut)
-			_, _ = fmt.Fprintf(out, "git worktree add failed: %v\n", addErr)
-			progress.UpdateStatus(r.RelPath, statusFailed, "worktree add failed")
-			return CommandResult{RelPath: r.RelPath, Error: addErr}
-		}
-
-		envSrc := filepath.Join(r.Path, ".env")
-		if _, statErr := os.Stat(envSrc); statErr == nil {
-			if copyErr := copyFile(envSrc, filepath.Join(wtPath, ".env")); copyErr == nil {
-				_, _ = fmt.Fprintln(out, "Copied .env to worktree.")
-			}
-		}
-		_, _ = fmt.Fprintf(out, "Worktree ready: %s\n", wtPath)
-
-		progress.UpdateStatus(r.RelPath, statusCompleted, "")
-		return CommandResult{RelPath: r.RelPath}
+		return createWorktree(ctx, r, branch, base, cfg, out, progress, nil)
 	})
 
-	success, failed, skipped := 0, 0, 0
+	success, failed, skipped, cancelled := 0, 0, 0, 0
 	for _, res := range results {
 		switch {
+		case res.Cancelled:
+			cancelled++
 		case res.Skipped:
 			skipped++
 		case res.Error != nil:
@@ -139,44 +141,125 @@ func worktreeCreate(ctx context.Context, root, branch, base string, workers int,
 	}
 
 	stop()
+	logManager.finish()
+	reportCI(cfg, "git worktree add "+branch, logManager, results)
+
+	if out.enabled() {
+		reportTimings(cfg, results)
+		return out.finishRun(failed, cancelled)
+	}
 
 	fmt.Println("\n" + StyleBold.Render("--- Summary ---"))
-	fmt.Printf("Created worktrees for '%s': %s succeeded, %s skipped, %s failed\n",
+	fmt.Printf("Created worktrees for '%s': %s succeeded, %s skipped, %s failed%s\n",
 		branch,
 		StyleSuccess.Render(fmt.Sprintf("%d", success)),
 		StyleSkipped.Render(fmt.Sprintf("%d", skipped)),
-		StyleFailed.Render(fmt.Sprintf("%d", failed)))
+		StyleFailed.Render(fmt.Sprintf("%d", failed)),
+		cancelledNote(cancelled))
+	reportTimings(cfg, results)
 
 	if PromptViewLogs() {
 		DisplayLogs(logManager, results)
 	} else {
-		fmt.Printf("\nLogs are available at: %s\n", logManager.GetTempDir())
+		printLogLocation(logManager)
 	}
 
-	if failed > 0 {
-		return errReposFailed
+	return runError(failed, cancelled)
+}
+
+func createWorktree(ctx context.Context, r RepoInfo, branch, base string, cfg *Config, out io.Writer, progress *ProgressState, plan *RepoPlan) CommandResult {
+	wtPath := worktreePath(r.Path, branch)
+
+	if _, statErr := os.Stat(wtPath); statErr == nil {
+		_, _ = fmt.Fprintf(out, "worktree already exists at %s\n", wtPath)
+		progress.UpdateStatus(r.RelPath, statusSkipped, "worktree already exists")
+		return CommandResult{RelPath: r.RelPath, Skipped: true}
 	}
-	return nil
+
+	if _, _, refErr := executeGitCommandWithRetry(ctx, r.Path, "show-ref", "--verify", "--quiet", "refs/heads/"+branch); refErr != nil {
+		repoBase := cfg.worktreeBaseFor(r.RelPath, base)
+		startPoint := repoBase
+		if !checkCommitExists(r.Path, repoBase) && !strings.Contains(repoBase, "/") && checkCommitExists(r.Path, cfg.remoteFor(r.RelPath)+"/"+repoBase) {
+			startPoint = cfg.remoteFor(r.RelPath) + "/" + repoBase
+		}
+		_, _ = fmt.Fprintf(out, "Creating branch '%s' from '%s'...\n", branch, startPoint)
+		if plan != nil && !checkCommitExists(r.Path, startPoint) {
+			_, _ = fmt.Fprintf(out, "Base '%s' not found\n", repoBase)
+			return CommandResult{RelPath: r.RelPath, Error: fmt.Errorf("base '%s' not found", repoBase)}
+		}
+		gitOut, bErr := gitStepWithRetry(ctx, r.Path, plan, "branch", branch, startPoint)
+		if bErr != nil {
+			_, _ = fmt.Fprintf(out, "%s", gitOut)
+			_, _ = fmt.Fprintf(out, "Failed to create branch: %v\n", bErr)
+			progress.UpdateStatus(r.RelPath, statusFailed, fmt.Sprintf("base '%s' not found", repoBase))
+			return CommandResult{RelPath: r.RelPath, Error: bErr}
+		}
+	}
+
+	if plan != nil {
+		plan.add("worktree", "add", wtPath, branch)
+		return CommandResult{RelPath: r.RelPath}
+	}
+
+	if mkErr := os.MkdirAll(filepath.Dir(wtPath), 0o755); mkErr != nil {
+		progress.UpdateStatus(r.RelPath, statusFailed, "mkdir failed")
+		return CommandResult{RelPath: r.RelPath, Error: mkErr}
+	}
+
+	gitOut, _, addErr := executeGitCommandWithRetry(ctx, r.Path, "worktree", "add", wtPath, branch)
+	if addErr != nil {
+		_, _ = fmt.Fprintf(out, "%s", gitOut)
+		_, _ = fmt.Fprintf(out, "git worktree add failed: %v\n", addErr)
+		progress.UpdateStatus(r.RelPath, statusFailed, "worktree add failed")
+		return CommandResult{RelPath: r.RelPath, Error: addErr}
+	}
+
+	envSrc := filepath.Join(r.Path, ".env")
+	if _, statErr := os.Stat(envSrc); statErr == nil {
+		if copyErr := copyFile(envSrc, filepath.Join(wtPath, ".env")); copyErr == nil {
+			_, _ = fmt.Fprintln(out, "Copied .env to worktree.")
+		}
+	}
+	_, _ = fmt.Fprintf(out, "Worktree ready: %s\n", wtPath)
+
+	progress.UpdateStatus(r.RelPath, statusCompleted, "")
+	return CommandResult{RelPath: r.RelPath}
+}
+
+func checkCommitExists(dir, rev string) bool {
+	cmd := newCmd("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	cmd.Dir = dir
+	return cmd.Run() == nil
 }
 
 func worktreeRemove(ctx context.Context, root, branch string, workers int, cfg *Config) error {
+	out := newResultWriter(cfg.Output, "worktree-remove")
 	repos, _ := discoverRepos(root, workers, cfg, true)
 	if repos == nil {
-		return nil
+		return out.finish(false)
 	}
 
 	isGlob := hasGlobMeta(branch)
-	fmt.Println(StyleInfo.Render(fmt.Sprintf("Removing worktrees for '%s' in %d repos with %d workers...", branch, len(repos), min(workers, len(repos)))))
+	if cfg.DryRun {
+		return planRepos(ctx, repos, workers, cfg, "git worktree remove "+branch, func(ctx context.Context, r RepoInfo, plan *RepoPlan) repoResult {
+			if isGlob {
+				return worktreeRemoveGlob(ctx, r, branch, io.Discard, &ProgressState{quiet: true}, plan)
+			}
+			return worktreeRemoveExact(ctx, r, branch, io.Discard, &ProgressState{quiet: true}, plan)
+		})
+	}
+
+	fmt.Fprintln(cfg.infoWriter(), StyleInfo.Render(fmt.Sprintf("Removing worktrees for '%s' in %d repos with %d workers...", branch, len(repos), min(workers, len(repos)))))
 
-	logManager, err := NewLogManager()
+	logManager, err := cfg.newLogManager(root, "git worktree remove "+branch)
 	if err != nil {
 		return fmt.Errorf("log manager: %w", err)
 	}
 
-	progress := NewProgressState(repos, fmt.Sprintf("Removing worktree '%s'", branch), cfg.PageSize)
+	progress := cfg.newProgress(repos, fmt.Sprintf("Removing worktree '%s'", branch), workers, logManager.expectedDurations(repos))
 	stop := progress.start()
 
-	results := runPool(ctx, repos, workers, func(ctx context.Context, r RepoInfo) CommandResult {
+	results := runPoolWith(ctx, repos, workers, newRunOptions[CommandResult](cfg, out, progress).withLogs(logManager), func(ctx context.Context, r RepoInfo) CommandResult {
 		progress.UpdateStatus(r.RelPath, statusProcessing, "")
 
 		logFile, _ := logManager.CreateLogFile(r.RelPath)
@@ -187,14 +270,16 @@ func worktreeRemove(ctx context.Context, root, branch string, workers int, cfg *
 		}
 
 		if isGlob {
-			return worktreeRemoveGlob(ctx, r, branch, out, progress)
+			return worktreeRemoveGlob(ctx, r, branch, out, progress, nil)
 		}
-		return worktreeRemoveExact(ctx, r, branch, out, progress)
+		return worktreeRemoveExact(ctx, r, branch, out, progress, nil)
 	})
 
-	success, failed, skipped := 0, 0, 0
+	success, failed, skipped, cancelled := 0, 0, 0, 0
 	for _, res := range results {
 		switch {
+		case res.Cancelled:
+			cancelled++
 		case res.Skipped:
 			skipped++
 		case res.Error != nil:
@@ -205,27 +290,33 @@ func worktreeRemove(ctx context.Context, root, branch string, workers int, cfg *
 	}
 
 	stop()
+	logManager.finish()
+	reportCI(cfg, "git worktree remove "+branch, logManager, results)
+
+	if out.enabled() {
+		reportTimings(cfg, results)
+		return out.finishRun(failed, cancelled)
+	}
 
 	fmt.Println("\n" + StyleBold.Render("--- Summary ---"))
-	fmt.Printf("Removed worktrees for '%s': %s succeeded, %s skipped, %s failed\n",
+	fmt.Printf("Removed worktrees for '%s': %s succeeded, %s skipped, %s failed%s\n",
 		branch,
 		StyleSuccess.Render(fmt.Sprintf("%d", success)),
 		StyleSkipped.Render(fmt.Sprintf("%d", skipped)),
-		StyleFailed.Render(fmt.Sprintf("%d", failed)))
+		StyleFailed.Render(fmt.Sprintf("%d", failed)),
+		cancelledNote(cancelled))
+	reportTimings(cfg, results)
 
 	if PromptViewLogs() {
 		DisplayLogs(logManager, results)
 	} else {
-		fmt.Printf("\nLogs are available at: %s\n", logManager.GetTempDir())
+		printLogLocation(logManager)
 	}
 
-	if failed > 0 {
-		return errReposFailed
-	}
-	return nil
+	return runError(failed, cancelled)
 }
 
-func worktreeRemoveGlob(ctx context.Context, r RepoInfo, pattern string, out io.Writer, progress *ProgressState) CommandResult {
+func worktreeRemoveGlob(ctx context.Context, r RepoInfo, pattern string, out io.Writer, progress *ProgressState, plan *RepoPlan) CommandResult {
 	porcelain, _, listErr := executeGitCommandWithRetry(ctx, r.Path, "worktree", "list", "--porcelain")
 	if listErr != nil {
 		_, _ = fmt.Fprintf(out, "git worktree list failed: %v\n", listErr)
@@ -248,13 +339,16 @@ func worktreeRemoveGlob(ctx context.Context, r RepoInfo, pattern string, out io.
 			continue
 		}
 		matched++
-		gitOut, _, removeErr := executeGitCommandWithRetry(ctx, r.Path, "worktree", "remove", wtPath)
+		gitOut, removeErr := gitStepWithRetry(ctx, r.Path, plan, "worktree", "remove", wtPath)
 		if removeErr != nil {
 			_, _ = fmt.Fprintf(out, "%s", gitOut)
 			_, _ = fmt.Fprintf(out, "git worktree remove failed for %s: %v\n", wtPath, removeErr)
 			cmdErr = removeErr
 			continue
 		}
+		if plan != nil {
+			continue
+		}
 		_, _ = fmt.Fprintf(out, "Removed worktree: %s\n", wtPath)
 		_ = os.Remove(filepath.Dir(wtPath))
 		_ = os.Remove(filepath.Dir(filepath.Dir(wtPath)))
@@ -273,7 +367,7 @@ func worktreeRemoveGlob(ctx context.Context, r RepoInfo, pattern string, out io.
 	return CommandResult{RelPath: r.RelPath}
 }
 
-func worktreeRemoveExact(ctx context.Context, r RepoInfo, branch string, out io.Writer, progress *ProgressState) CommandResult {
+func worktreeRemoveExact(ctx context.Context, r RepoInfo, branch string, out io.Writer, progress *ProgressState, plan *RepoPlan) CommandResult {
 	var wtPath string
 	if porcelain, _, listErr := executeGitCommandWithRetry(ctx, r.Path, "worktree", "list", "--porcelain"); listErr == nil {
 		for _, pair := range parseWorktreeList(string(porcelain)) {
@@ -299,13 +393,16 @@ func worktreeRemoveExact(ctx context.Context, r RepoInfo, branch string, out io.
 		return CommandResult{RelPath: r.RelPath, Skipped: true}
 	}
 
-	gitOut, _, removeErr := executeGitCommandWithRetry(ctx, r.Path, "worktree", "remove", wtPath)
+	gitOut, removeErr := gitStepWithRetry(ctx, r.Path, plan, "worktree", "remove", wtPath)
 	if removeErr != nil {
 		_, _ = fmt.Fprintf(out, "%s", gitOut)
 		_, _ = fmt.Fprintf(out, "git worktree remove failed: %v\n", removeErr)
 		progress.UpdateStatus(r.RelPath, statusFailed, "worktree remove failed")
 		return CommandResult{RelPath: r.RelPath, Error: removeErr}
 	}
+	if plan != nil {
+		return CommandResult{RelPath: r.RelPath}
+	}
 	_, _ = fmt.Fprintf(out, "Removed worktree: %s\n", wtPath)
 	_ = os.Remove(filepath.Dir(wtPath))
 	_ = os.Remove(filepath.Dir(filepath.Dir(wtPath)))
@@ -314,18 +411,13 @@ func worktreeRemoveExact(ctx context.Context, r RepoInfo, branch string, out io.
 }
 
 func worktreeOpen(ctx context.Context, root, branch string, workers int, cfg *Config) error {
+	out := newResultWriter(cfg.Output, "worktree-open")
 	repos, _ := discoverRepos(root, workers, cfg, true)
 	if repos == nil {
-		return nil
+		return out.finish(false)
 	}
 
-	type pathResult struct {
-		relPath string
-		wtPath  string
-		exists  bool
-	}
-
-	results := runPool(ctx, repos, workers, func(ctx context.Context, r RepoInfo) pathResult {
+	results := runPoolWith(ctx, repos, workers, newPoolOptions[WorktreePathResult](out), func(ctx context.Context, r RepoInfo) WorktreePathResult {
 		var wtPath string
 		if porcelain, _, listErr := executeGitCommandWithRetry(ctx, r.Path, "worktree", "list", "--porcelain"); listErr == nil {
 			for _, pair := range parseWorktreeList(string(porcelain)) {
@@ -339,16 +431,20 @@ func worktreeOpen(ctx context.Context, root, branch string, workers int, cfg *Co
 			wtPath = worktreePath(r.Path, branch)
 		}
 		_, statErr := os.Stat(wtPath)
-		return pathResult{relPath: r.RelPath, wtPath: wtPath, exists: statErr == nil}
+		return WorktreePathResult{RelPath: r.RelPath, Path: wtPath, Exists: statErr == nil}
 	})
 
-	sort.Slice(results, func(i, j int) bool { return results[i].relPath < results[j].relPath })
+	if out.enabled() {
+		return out.finish(false)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].RelPath < results[j].RelPath })
 
 	for _, e := range results {
-		if e.exists {
-			fmt.Printf("%s %s\n", StyleSuccess.Render(e.relPath+":"), e.wtPath)
+		if e.Exists {
+			fmt.Printf("%s %s\n", StyleSuccess.Render(e.RelPath+":"), e.Path)
 		} else {
-			fmt.Printf("%s %s\n", StyleDim.Render(e.relPath+":"), StyleDim.Render("(no worktree)"))
+			fmt.Printf("%s %s\n", StyleDim.Render(e.RelPath+":"), StyleDim.Render("(no worktree)"))
 		}
 	}
 	return nil